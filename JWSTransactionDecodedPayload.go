@@ -0,0 +1,77 @@
+package apple
+
+type JWSTransactionDecodedPayload struct {
+	// Transaction identifiers
+	OriginalTransactionId string `json:"originalTransactionId"` // 与此交易关联的原始购买的交易标识符。
+	TransactionId         string `json:"transactionId"`         // 交易的唯一标识符，例如应用内购买、恢复购买或订阅续订。
+	WebOrderLineItemId    string `json:"webOrderLineItemId"`    // 跨设备订阅购买事件的唯一标识符，包括订阅续订。
+	AppTransactionId      string `json:"appTransactionId"`      // 同一 Apple 账户下应用下载的唯一标识符。
+
+	// App information
+	BundleId string `json:"bundleId"` // The bundle identifier of an app.
+
+	// Account information
+	AppAccountToken *string `json:"appAccountToken"` // 将交易与您服务上的客户关联起来的 UUID。
+
+	// Product information
+	ProductId                   string      `json:"productId"`                   // 应用内购买的产品标识符。
+	Type                        ProductType `json:"type"`                        // 应用内购买的产品类型。
+	SubscriptionGroupIdentifier string      `json:"subscriptionGroupIdentifier"` // 订阅所属订阅组的标识符。
+	Quantity                    *int32      `json:"quantity"`                    // 购买的消耗品的数量。
+
+	// Product price and currency
+	Price    *int64 `json:"price"`    // 系统在交易中记录的应用内购买的价格（以毫为单位）。
+	Currency string `json:"currency"` // 价格的三字母 ISO 4217 货币代码。
+
+	// Storefront information
+	Storefront   string `json:"storefront"`   // 代表与购买的 App Store 店面关联的国家或地区的三字母代码。
+	StorefrontId string `json:"storefrontId"` // Apple 定义的值，用于唯一标识 App Store 店面。
+
+	// Subscription offers
+	OfferType         OfferType         `json:"offerType"`         // 订阅优惠的类型。
+	OfferIdentifier   string            `json:"offerIdentifier"`   // 优惠代码或促销优惠标识符。
+	OfferDiscountType OfferDiscountType `json:"offerDiscountType"` // 折扣优惠的付款方式。
+	OfferPeriod       string            `json:"offerPeriod"`       // 优惠的时长，ISO 8601 格式（例如 P1M）。
+
+	// Purchase dates
+	OriginalPurchaseDate Timestamp `json:"originalPurchaseDate"` // 与原始交易标识符关联的交易的购买日期。
+	PurchaseDate         Timestamp `json:"purchaseDate"`         // App Store 向客户的帐户收取购买、恢复产品、订阅或订阅过期后续订费用的时间。
+
+	// Subscription expiration
+	ExpiresDate Timestamp `json:"expiresDate"` // 订阅到期或续订的 UNIX 时间（以毫秒为单位）。
+	IsUpgraded  bool      `json:"isUpgraded"`  // 一个布尔值，指示客户是否升级到另一个订阅。
+
+	// Family Sharing
+	InAppOwnershipType InAppOwnershipType `json:"inAppOwnershipType"` // 描述交易是否由客户购买，或者是否可以通过家庭共享提供给客户的字符串。
+
+	// Revocation date and reason
+	RevocationDate   Timestamp         `json:"revocationDate"`   // App Store 退款或从家庭共享中撤销交易的 UNIX 时间（以毫秒为单位）。
+	RevocationReason *RevocationReason `json:"revocationReason"` // 交易退款的原因。
+
+	// Transaction reason
+	TransactionReason TransactionReason `json:"transactionReason"` // 购买交易的原因，表明是客户的购买还是系统发起的自动续订订阅的续订。
+
+	// JWS signature date
+	SignedDate Timestamp `json:"signedDate"` // App Store 签署 JSON Web 签名 (JWS) 数据的 UNIX 时间（以毫秒为单位）。
+
+	Environment Environment `json:"environment"` // 服务器环境，沙箱或生产环境。
+}
+
+// DecodeJWSTransaction decodes the payload of a JWSTransaction
+func DecodeJWSTransaction(jws string) (*JWSTransactionDecodedPayload, error) {
+	var transaction JWSTransactionDecodedPayload
+	if err := decodeSignedPayload(jws, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// VerifyJWSTransaction verifies the signature of the JWSTransaction against the roots in store,
+// a nil store trusts the embedded Apple root certificates
+func VerifyJWSTransaction(store *TrustStore, jws string) (*JWSTransactionDecodedPayload, error) {
+	payload := &JWSTransactionDecodedPayload{}
+	if err := verifySignedPayload(store, jws, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -1,11 +1,11 @@
 package apple
 
 import (
+	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
-	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"strings"
@@ -14,16 +14,9 @@ import (
 
 // GenerateAuthorizationJWT 生成 Apple App Store Server API 的 JWT
 func GenerateAuthorizationJWT(Kid, Bid, Iss, privateKeyStr string) (string, error) {
-	// 解析 PEM 格式的私钥
-	block, _ := pem.Decode([]byte(privateKeyStr))
-	if block == nil || block.Type != "PRIVATE KEY" {
-		return "", fmt.Errorf("failed to parse private key: invalid PEM format")
-	}
-
-	// 解析 EC 私钥
-	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	privateKey, err := parsePrivateKey(privateKeyStr)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse EC private key: %v", err)
+		return "", err
 	}
 
 	// 创建 JWT 的 Header 和 Claims
@@ -49,110 +42,88 @@ func GenerateAuthorizationJWT(Kid, Bid, Iss, privateKeyStr string) (string, erro
 	return signedToken, nil
 }
 
-// VerifyJWT verifies the JWT signature using the Apple JWK
-func VerifyJWT(jws string) error {
-	// Fetch Apple's JWKs
-	jwk, err := FetchAppleJWKs()
-	if err != nil {
-		return fmt.Errorf("failed to fetch Apple JWKs: %v", err)
+// parsePrivateKey 解析 App Store Connect 下载的 .p8 私钥（PKCS#8 编码的 EC 私钥），也兼容 SEC 1 编码的 EC PRIVATE KEY
+func parsePrivateKey(privateKeyStr string) (*ecdsa.PrivateKey, error) {
+	// 解析 PEM 格式的私钥
+	block, _ := pem.Decode([]byte(privateKeyStr))
+	if block == nil || (block.Type != "PRIVATE KEY" && block.Type != "EC PRIVATE KEY") {
+		return nil, fmt.Errorf("failed to parse private key: invalid PEM format")
 	}
 
-	// Parse the JWT to extract the header and kid
-	token, _, err := jwt.NewParser().ParseUnverified(jws, jwt.MapClaims{})
-	if err != nil {
-		return fmt.Errorf("failed to parse JWT: %v", err)
-	}
-	kid, ok := token.Header["kid"].(string)
-	if !ok {
-		return errors.New("kid not found in JWT header")
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		privateKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse private key: %T is not an EC private key", key)
+		}
+		return privateKey, nil
 	}
 
-	// Get the RSA public key for the given kid
-	pubKey, err := GetAppleRSAPublicKey(*jwk, kid)
+	// 解析 EC 私钥
+	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
 	if err != nil {
-		return fmt.Errorf("failed to get RSA public key: %v", err)
+		return nil, fmt.Errorf("failed to parse EC private key: %v", err)
 	}
+	return privateKey, nil
+}
+
+// VerifyJWT verifies the signature of an App Store signed JWS against the roots in store,
+// a nil store trusts the embedded Apple root certificates
+func VerifyJWT(store *TrustStore, jws string) error {
+	var claims map[string]interface{}
+	return verifySignedPayload(store, jws, &claims)
+}
 
-	// Verify the JWT signature
-	parsedToken, err := jwt.Parse(jws, func(token *jwt.Token) (interface{}, error) {
-		// Ensure the token uses the correct signing method
-		if _, ok = token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// verifySignedPayload 校验 App Store 签名数据（ES256 JWS）并将 payload 解析到 v：
+// 使用头部 x5c 证书链校验 叶子 -> 中间 -> store 中的根证书，证书有效期以 payload 中的 signedDate 为准，
+// 再使用叶子证书的公钥校验签名。证书被吊销时返回的错误可以用 errors.As 取出 *RevocationError
+func verifySignedPayload(store *TrustStore, jws string, v any) error {
+	// signedDate 缺失时以当前时间校验证书有效期
+	var signed struct {
+		SignedDate Timestamp `json:"signedDate"`
+	}
+	if err := decodeSignedPayload(jws, &signed); err != nil {
+		return err
+	}
+	at := time.Now()
+	if signed.SignedDate != 0 {
+		at = *signed.SignedDate.Time()
+	}
+
+	// App Store 的签名数据本身没有 exp 等标准声明，过期的交易也是合法签名，因此跳过 claims 校验
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}),
+		jwt.WithoutClaimsValidation(),
+	)
+	_, err := parser.ParseWithClaims(jws, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
+		chain, err := parseX5C(token.Header)
+		if err != nil {
+			return nil, err
 		}
-		return pubKey, nil
+		return store.verifyCertificateChain(chain, at)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to verify JWT: %v", err)
-	}
-
-	// Check if the token is valid
-	if !parsedToken.Valid {
-		return errors.New("invalid JWT")
+		return fmt.Errorf("failed to verify JWS: %w", err)
 	}
-
-	return nil
+	return decodeSignedPayload(jws, v)
 }
 
-// DecodeJWSTransaction decodes the payload of a JWSTransaction
-func DecodeJWSTransaction(jws string) (*SubscriptionInfo, error) {
+// decodeSignedPayload 不校验签名，仅将 JWS 的 payload 解析到 v
+func decodeSignedPayload(jws string, v any) error {
 	// Split the JWT into three parts: header, payload, signature
 	parts := strings.Split(jws, ".")
 	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid JWT format")
+		return fmt.Errorf("invalid JWT format")
 	}
 
 	// Decode the payload (Base64 URL encoded)
 	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode payload: %v", err)
+		return fmt.Errorf("failed to decode payload: %v", err)
 	}
 
 	// Unmarshal the JSON payload into the struct
-	var transaction SubscriptionInfo
-	if err = json.Unmarshal(payload, &transaction); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal payload: %v", err)
+	if err = json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %v", err)
 	}
-
-	return &transaction, nil
-}
-
-// VerifyJWSTransaction verifies the signature of the JWSTransaction
-func VerifyJWSTransaction(jws string) (*SubscriptionInfo, error) {
-	// Fetch Apple's JWKs
-	jwk, err := FetchAppleJWKs()
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch Apple JWKs: %v", err)
-	}
-
-	// Parse the JWT to extract the header and kid
-	token, _, err := jwt.NewParser().ParseUnverified(jws, jwt.MapClaims{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse JWT: %v", err)
-	}
-	kid, ok := token.Header["kid"].(string)
-	if !ok {
-		return nil, fmt.Errorf("kid not found in JWT header")
-	}
-
-	// Get the ECDSA public key for the given kid
-	pubKey, err := GetAppleRSAPublicKey(*jwk, kid)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get ECDSA public key: %v", err)
-	}
-
-	// Verify the JWT signature
-	parsedToken, err := jwt.ParseWithClaims(jws, &SubscriptionInfo{}, func(token *jwt.Token) (interface{}, error) {
-		return pubKey, nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to verify JWT: %v", err)
-	}
-
-	// Assert the claims as JWSTransactionPayload
-	payload, ok := parsedToken.Claims.(*SubscriptionInfo)
-	if !ok {
-		return nil, fmt.Errorf("failed to parse JWT claims as JWSTransactionPayload")
-	}
-
-	return payload, nil
+	return nil
 }
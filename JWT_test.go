@@ -0,0 +1,262 @@
+package apple
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testPKI 模拟 Apple 的 根 -> 中间 -> 叶子 证书链，中间证书和叶子证书带有 Apple 标记扩展
+type testPKI struct {
+	root, intermediate, leaf          *x509.Certificate
+	rootKey, intermediateKey, leafKey *ecdsa.PrivateKey
+}
+
+// newTestPKI 生成有效期为当前时间前后两天的证书链，ocspURL 不为空时写入中间证书和叶子证书的 OCSP 地址
+func newTestPKI(t *testing.T, ocspURL string) *testPKI {
+	t.Helper()
+	p := &testPKI{
+		rootKey:         newTestKey(t),
+		intermediateKey: newTestKey(t),
+		leafKey:         newTestKey(t),
+	}
+	notBefore, notAfter := time.Now().Add(-48*time.Hour), time.Now().Add(48*time.Hour)
+	var ocspServer []string
+	if ocspURL != "" {
+		ocspServer = []string{ocspURL}
+	}
+
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	p.root = newTestCertificate(t, root, root, &p.rootKey.PublicKey, p.rootKey)
+
+	intermediate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		OCSPServer:            ocspServer,
+		ExtraExtensions:       []pkix.Extension{{Id: oidAppleIntermediateMarker, Value: []byte{0x05, 0x00}}},
+	}
+	p.intermediate = newTestCertificate(t, intermediate, p.root, &p.intermediateKey.PublicKey, p.rootKey)
+
+	leaf := &x509.Certificate{
+		SerialNumber:    big.NewInt(3),
+		Subject:         pkix.Name{CommonName: "Test Leaf"},
+		NotBefore:       notBefore,
+		NotAfter:        notAfter,
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		OCSPServer:      ocspServer,
+		ExtraExtensions: []pkix.Extension{{Id: oidAppleLeafMarker, Value: []byte{0x05, 0x00}}},
+	}
+	p.leaf = newTestCertificate(t, leaf, p.intermediate, &p.leafKey.PublicKey, p.intermediateKey)
+	return p
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newTestCertificate(t *testing.T, template, parent *x509.Certificate, pub *ecdsa.PublicKey, priv *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// sign 使用叶子证书签名 claims，头部 x5c 为 叶子、中间、根
+func (p *testPKI) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["x5c"] = []string{
+		base64.StdEncoding.EncodeToString(p.leaf.Raw),
+		base64.StdEncoding.EncodeToString(p.intermediate.Raw),
+		base64.StdEncoding.EncodeToString(p.root.Raw),
+	}
+	jws, err := token.SignedString(p.leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jws
+}
+
+func TestVerifySignedPayload(t *testing.T) {
+	p := newTestPKI(t, "")
+	store := NewTrustStore(p.root)
+	now := time.Now()
+	valid := p.sign(t, jwt.MapClaims{"productId": "com.example.monthly", "signedDate": now.UnixMilli()})
+
+	// 替换 payload，保留原来的签名
+	parts := strings.Split(valid, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"productId":"com.example.yearly","signedDate":` +
+		strconv.FormatInt(now.UnixMilli(), 10) + `}`))
+	tampered := strings.Join(parts, ".")
+
+	tests := []struct {
+		name      string
+		store     *TrustStore
+		jws       string
+		wantChain bool // 是否应为证书链错误
+		wantErr   bool
+	}{
+		{name: "valid chain", store: store, jws: valid},
+		{name: "wrong root", store: NewTrustStore(newTestPKI(t, "").root), jws: valid, wantChain: true, wantErr: true},
+		{name: "default apple root", store: nil, jws: valid, wantChain: true, wantErr: true},
+		{name: "tampered payload", store: store, jws: tampered, wantErr: true},
+		{
+			name:      "signed before certificates were valid",
+			store:     store,
+			jws:       p.sign(t, jwt.MapClaims{"productId": "com.example.monthly", "signedDate": now.Add(-30 * 24 * time.Hour).UnixMilli()}),
+			wantChain: true,
+			wantErr:   true,
+		},
+		{
+			name:      "signed after certificates expired",
+			store:     store,
+			jws:       p.sign(t, jwt.MapClaims{"productId": "com.example.monthly", "signedDate": now.Add(30 * 24 * time.Hour).UnixMilli()}),
+			wantChain: true,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload JWSRenewalInfoDecodedPayload
+			err := verifySignedPayload(tt.store, tt.jws, &payload)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("verifySignedPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantChain != errors.Is(err, ErrInvalidCertificateChain) {
+				t.Fatalf("verifySignedPayload() error = %v, want ErrInvalidCertificateChain %v", err, tt.wantChain)
+			}
+			if err == nil && payload.ProductId != "com.example.monthly" {
+				t.Fatalf("productId = %q, want %q", payload.ProductId, "com.example.monthly")
+			}
+			if jwtErr := VerifyJWT(tt.store, tt.jws); (jwtErr != nil) != tt.wantErr {
+				t.Fatalf("VerifyJWT() error = %v, wantErr %v", jwtErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyCertificateChain(t *testing.T) {
+	p := newTestPKI(t, "")
+	other := newTestPKI(t, "")
+	store := NewTrustStore(p.root)
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		leaf         *x509.Certificate
+		intermediate *x509.Certificate
+		at           time.Time
+		wantErr      bool
+	}{
+		{name: "valid chain", leaf: p.leaf, intermediate: p.intermediate, at: now},
+		{name: "wrong root", leaf: other.leaf, intermediate: other.intermediate, at: now, wantErr: true},
+		{name: "intermediate does not sign leaf", leaf: p.leaf, intermediate: other.intermediate, at: now, wantErr: true},
+		{name: "missing leaf marker", leaf: p.intermediate, intermediate: p.intermediate, at: now, wantErr: true},
+		{name: "missing intermediate marker", leaf: p.leaf, intermediate: p.root, at: now, wantErr: true},
+		{name: "before certificates were valid", leaf: p.leaf, intermediate: p.intermediate, at: now.Add(-72 * time.Hour), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.verifyCertificateChain([]*x509.Certificate{tt.leaf, tt.intermediate}, tt.at)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("verifyCertificateChain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCertificateChain) {
+				t.Fatalf("verifyCertificateChain() error = %v, want ErrInvalidCertificateChain", err)
+			}
+		})
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key := newTestKey(t)
+	sec1, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaDER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pem     string
+		wantErr bool
+	}{
+		{name: "pkcs8 p8 file", pem: newTestP8(t, key)},
+		{name: "sec1 ec private key", pem: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}))},
+		{name: "pkcs8 rsa key", pem: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rsaDER})), wantErr: true},
+		{name: "not pem", pem: "MIGTAgEAMBMGByqGSM49", wantErr: true},
+		{name: "wrong block type", pem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: sec1})), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePrivateKey(tt.pem)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("parsePrivateKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(key) {
+				t.Fatal("parsePrivateKey() returned another key")
+			}
+
+			// GenerateAuthorizationJWT 接受同样的私钥，签名可以用公钥校验
+			token, err := GenerateAuthorizationJWT("KEY123", "com.example", "issuer", tt.pem)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("GenerateAuthorizationJWT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil },
+				jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}), jwt.WithAudience("appstoreconnect-v1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Header["kid"] != "KEY123" {
+				t.Fatalf("kid = %v", parsed.Header["kid"])
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// UpdateAppAccountTokenRequest 设置交易 appAccountToken 的请求
+type UpdateAppAccountTokenRequest struct {
+	AppAccountToken string `json:"appAccountToken"` // 关联到交易的 UUID，一般为业务系统中客户账号的标识。
+}
+
+// Validate 检查请求参数
+func (r *UpdateAppAccountTokenRequest) Validate() error {
+	if r.AppAccountToken == "" {
+		return errors.New("appAccountToken is required")
+	}
+	if !isUUID(r.AppAccountToken) {
+		return fmt.Errorf("appAccountToken %q is not a UUID", r.AppAccountToken)
+	}
+	return nil
+}
+
+// SetAppAccountToken 为已有的交易设置 appAccountToken（Set App Account Token），
+// 用于关联应用开始传入 appAccountToken 之前的购买：
+// originalTransactionId 交易的原始交易ID，订阅的后续续订交易同样生效
+// appAccountToken 客户账号的 UUID
+func (c *Client) SetAppAccountToken(ctx context.Context, originalTransactionId, appAccountToken string) error {
+	request := &UpdateAppAccountTokenRequest{AppAccountToken: appAccountToken}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	endpoint := c.endpoint("/inApps/v1/transactions/%s/appAccountToken", url.PathEscape(originalTransactionId))
+	return c.sendJSON(ctx, http.MethodPut, endpoint, request, nil)
+}
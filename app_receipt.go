@@ -0,0 +1,228 @@
+package apple
+
+import (
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 应用收据中的属性类型
+const (
+	receiptAttributeBundleId                   = 2
+	receiptAttributeApplicationVersion         = 3
+	receiptAttributeReceiptCreationDate        = 12
+	receiptAttributeInApp                      = 17
+	receiptAttributeOriginalApplicationVersion = 19
+	receiptAttributeExpirationDate             = 21
+
+	inAppAttributeQuantity              = 1701
+	inAppAttributeProductId             = 1702
+	inAppAttributeTransactionId         = 1703
+	inAppAttributePurchaseDate          = 1704
+	inAppAttributeOriginalTransactionId = 1705
+	inAppAttributeOriginalPurchaseDate  = 1706
+	inAppAttributeExpiresDate           = 1708
+	inAppAttributeWebOrderLineItemId    = 1711
+	inAppAttributeCancellationDate      = 1712
+	inAppAttributeIsTrialPeriod         = 1713
+	inAppAttributeIsInIntroOfferPeriod  = 1719
+)
+
+// AppReceipt 本地解析的应用收据（StoreKit 1 的 appStoreReceiptURL 中的 PKCS#7 收据），没有的时间为零值
+type AppReceipt struct {
+	BundleId                   string             // 应用的 Bundle ID
+	ApplicationVersion         string             // 应用的版本号（CFBundleVersion）
+	OriginalApplicationVersion string             // 客户首次购买的应用版本号
+	ReceiptCreationDate        time.Time          // 收据创建的时间
+	ExpirationDate             time.Time          // 收据过期的时间，仅用于批量购买计划
+	InApp                      []*AppReceiptInApp // 收据中的应用内购买交易
+}
+
+// AppReceiptInApp 应用收据中的一笔应用内购买交易
+type AppReceiptInApp struct {
+	Quantity              int64     // 购买的数量
+	ProductId             string    // 产品标识符
+	TransactionId         string    // 交易标识符
+	OriginalTransactionId string    // 原始购买的交易标识符
+	PurchaseDate          time.Time // 购买或续订的时间
+	OriginalPurchaseDate  time.Time // 原始购买的时间
+	ExpiresDate           time.Time // 订阅到期的时间，仅自动续订订阅
+	WebOrderLineItemId    int64     // 跨设备的订阅购买事件标识符
+	CancellationDate      time.Time // 退款或撤销的时间
+	IsTrialPeriod         bool      // 是否处于免费试用期
+	IsInIntroOfferPeriod  bool      // 是否处于推介促销期
+}
+
+type receiptAttribute struct {
+	Type    int
+	Version int
+	Value   []byte
+}
+
+// DecodeAppReceipt 解析 Base64 编码的应用收据，不校验签名，只应用于已通过其他方式确认可信的收据
+func DecodeAppReceipt(receipt string) (*AppReceipt, error) {
+	p7, err := parseAppReceiptPKCS7(receipt)
+	if err != nil {
+		return nil, err
+	}
+	return parseAppReceiptPayload(p7.content)
+}
+
+// VerifyAppReceipt 校验应用收据的签名后解析：签名证书需要经由收据中的中间证书链接到 store 中的根证书，
+// 并在收据创建时有效。Apple 使用 Apple Root CA（RSA）下的证书签名收据，
+// store 为 nil 时使用 ReceiptTrustStore，即内置的 Apple Root CA，而不是校验 JWS 使用的 Apple Root CA - G3
+func VerifyAppReceipt(store *TrustStore, receipt string) (*AppReceipt, error) {
+	if store == nil {
+		store = defaultReceiptTrustStore
+	}
+
+	p7, err := parseAppReceiptPKCS7(receipt)
+	if err != nil {
+		return nil, err
+	}
+	appReceipt, err := parseAppReceiptPayload(p7.content)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, intermediate, err := p7.signerCertificate()
+	if err != nil {
+		return nil, err
+	}
+	at := appReceipt.ReceiptCreationDate
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if err = store.verifyLeaf(leaf, intermediate, at); err != nil {
+		return nil, err
+	}
+	if err = p7.verifySignature(leaf); err != nil {
+		return nil, err
+	}
+	return appReceipt, nil
+}
+
+func parseAppReceiptPKCS7(receipt string) (*pkcs7, error) {
+	// 客户端上传的收据可能带有换行
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(receipt), ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode app receipt: %v", err)
+	}
+	p7, err := parsePKCS7(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse app receipt: %v", err)
+	}
+	return p7, nil
+}
+
+func parseAppReceiptPayload(payload []byte) (*AppReceipt, error) {
+	attributes, err := parseReceiptAttributes(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &AppReceipt{}
+	for _, attribute := range attributes {
+		switch attribute.Type {
+		case receiptAttributeBundleId:
+			err = unmarshalReceiptString(attribute.Value, &receipt.BundleId)
+		case receiptAttributeApplicationVersion:
+			err = unmarshalReceiptString(attribute.Value, &receipt.ApplicationVersion)
+		case receiptAttributeOriginalApplicationVersion:
+			err = unmarshalReceiptString(attribute.Value, &receipt.OriginalApplicationVersion)
+		case receiptAttributeReceiptCreationDate:
+			err = unmarshalReceiptDate(attribute.Value, &receipt.ReceiptCreationDate)
+		case receiptAttributeExpirationDate:
+			err = unmarshalReceiptDate(attribute.Value, &receipt.ExpirationDate)
+		case receiptAttributeInApp:
+			var inApp *AppReceiptInApp
+			if inApp, err = parseAppReceiptInApp(attribute.Value); err == nil {
+				receipt.InApp = append(receipt.InApp, inApp)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid app receipt attribute %d: %v", attribute.Type, err)
+		}
+	}
+	return receipt, nil
+}
+
+func parseAppReceiptInApp(payload []byte) (*AppReceiptInApp, error) {
+	attributes, err := parseReceiptAttributes(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	inApp := &AppReceiptInApp{}
+	for _, attribute := range attributes {
+		var flag int64
+		switch attribute.Type {
+		case inAppAttributeQuantity:
+			_, err = asn1.Unmarshal(attribute.Value, &inApp.Quantity)
+		case inAppAttributeProductId:
+			err = unmarshalReceiptString(attribute.Value, &inApp.ProductId)
+		case inAppAttributeTransactionId:
+			err = unmarshalReceiptString(attribute.Value, &inApp.TransactionId)
+		case inAppAttributeOriginalTransactionId:
+			err = unmarshalReceiptString(attribute.Value, &inApp.OriginalTransactionId)
+		case inAppAttributePurchaseDate:
+			err = unmarshalReceiptDate(attribute.Value, &inApp.PurchaseDate)
+		case inAppAttributeOriginalPurchaseDate:
+			err = unmarshalReceiptDate(attribute.Value, &inApp.OriginalPurchaseDate)
+		case inAppAttributeExpiresDate:
+			err = unmarshalReceiptDate(attribute.Value, &inApp.ExpiresDate)
+		case inAppAttributeWebOrderLineItemId:
+			_, err = asn1.Unmarshal(attribute.Value, &inApp.WebOrderLineItemId)
+		case inAppAttributeCancellationDate:
+			err = unmarshalReceiptDate(attribute.Value, &inApp.CancellationDate)
+		case inAppAttributeIsTrialPeriod:
+			_, err = asn1.Unmarshal(attribute.Value, &flag)
+			inApp.IsTrialPeriod = flag != 0
+		case inAppAttributeIsInIntroOfferPeriod:
+			_, err = asn1.Unmarshal(attribute.Value, &flag)
+			inApp.IsInIntroOfferPeriod = flag != 0
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid in-app attribute %d: %v", attribute.Type, err)
+		}
+	}
+	return inApp, nil
+}
+
+// parseReceiptAttributes 解析 SET OF ReceiptAttribute ::= SEQUENCE { type INTEGER, version INTEGER, value OCTET STRING }
+func parseReceiptAttributes(payload []byte) ([]receiptAttribute, error) {
+	der, err := berToDER(payload)
+	if err != nil {
+		return nil, err
+	}
+	var attributes []receiptAttribute
+	if _, err = asn1.UnmarshalWithParams(der, &attributes, "set"); err != nil {
+		return nil, fmt.Errorf("failed to parse receipt attributes: %v", err)
+	}
+	return attributes, nil
+}
+
+// unmarshalReceiptString 解析 UTF8String 或 IA5String
+func unmarshalReceiptString(value []byte, s *string) error {
+	_, err := asn1.Unmarshal(value, s)
+	return err
+}
+
+// unmarshalReceiptDate 解析 RFC 3339 格式的 IA5String，空字符串表示没有
+func unmarshalReceiptDate(value []byte, t *time.Time) error {
+	var s string
+	if err := unmarshalReceiptString(value, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
@@ -0,0 +1,18 @@
+package apple
+
+// AppTransaction 应用交易信息（StoreKit AppTransaction 的 JWS 解码后的内容）
+type AppTransaction struct {
+	ReceiptType                Environment `json:"receiptType"`                // 应用交易所在的服务器环境，沙箱或生产环境。
+	AppAppleId                 int64       `json:"appAppleId"`                 // 应用在 App Store 中的唯一标识符。
+	BundleId                   string      `json:"bundleId"`                   // 应用的 Bundle ID。
+	ApplicationVersion         string      `json:"applicationVersion"`         // 应用的版本号。
+	VersionExternalIdentifier  int64       `json:"versionExternalIdentifier"`  // App Store 用于唯一标识应用版本的标识符。
+	ReceiptCreationDate        Timestamp   `json:"receiptCreationDate"`        // App Store 创建此应用交易的时间。
+	OriginalPurchaseDate       Timestamp   `json:"originalPurchaseDate"`       // 用户首次购买或下载应用的时间。
+	OriginalApplicationVersion string      `json:"originalApplicationVersion"` // 用户首次购买或下载的应用版本号。
+	DeviceVerification         string      `json:"deviceVerification"`         // 用于校验应用交易属于当前设备的 Base64 设备校验值。
+	DeviceVerificationNonce    string      `json:"deviceVerificationNonce"`    // 计算设备校验值时使用的 UUID。
+	PreorderDate               Timestamp   `json:"preorderDate"`               // 用户预订应用的时间。
+	AppTransactionId           string      `json:"appTransactionId"`           // 同一 Apple 账户下应用下载的唯一标识符。
+	OriginalPlatform           string      `json:"originalPlatform"`           // 用户首次购买应用的平台。
+}
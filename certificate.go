@@ -0,0 +1,277 @@
+package apple
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// appleRootCAG3 Apple Root CA - G3 根证书，App Store 签名数据（JWS）的证书链最终由它签发。
+// SHA-256 指纹: 63:34:3A:BF:B8:9A:6A:03:EB:B5:7E:9B:3F:5F:A7:BE:7C:4F:5C:75:6F:30:17:B3:A8:C4:88:C3:65:3E:91:79
+const appleRootCAG3 = `-----BEGIN CERTIFICATE-----
+MIICQzCCAcmgAwIBAgIILcX8iNLFS5UwCgYIKoZIzj0EAwMwZzEbMBkGA1UEAwwS
+QXBwbGUgUm9vdCBDQSAtIEczMSYwJAYDVQQLDB1BcHBsZSBDZXJ0aWZpY2F0aW9u
+IEF1dGhvcml0eTETMBEGA1UECgwKQXBwbGUgSW5jLjELMAkGA1UEBhMCVVMwHhcN
+MTQwNDMwMTgxOTA2WhcNMzkwNDMwMTgxOTA2WjBnMRswGQYDVQQDDBJBcHBsZSBS
+b290IENBIC0gRzMxJjAkBgNVBAsMHUFwcGxlIENlcnRpZmljYXRpb24gQXV0aG9y
+aXR5MRMwEQYDVQQKDApBcHBsZSBJbmMuMQswCQYDVQQGEwJVUzB2MBAGByqGSM49
+AgEGBSuBBAAiA2IABJjpLz1AcqTtkyJygRMc3RCV8cWjTnHcFBbZDuWmBSp3ZHtf
+TjjTuxxEtX/1H7YyYl3J6YRbTzBPEVoA/VhYDKX1DyxNB0cTddqXl5dvMVztK517
+IDvYuVTZXpmkOlEKMaNCMEAwHQYDVR0OBBYEFLuw3qFYM4iapIqZ3r6966/ayySr
+MA8GA1UdEwEB/wQFMAMBAf8wDgYDVR0PAQH/BAQDAgEGMAoGCCqGSM49BAMDA2gA
+MGUCMQCD6cHEFl4aXTQY2e3v9GwOAEZLuN+yRhHFD/3meoyhpmvOwgPUnPWTxnS4
+at+qIxUCMG1mihDK1A3UT82NQz60imOlM27jbdoXt2QfyFMm+YhidDkLF1vLUagM
+6BgD56KyKA==
+-----END CERTIFICATE-----`
+
+// appleRootCA Apple Root CA（RSA）根证书，应用收据（PKCS#7）的签名证书链最终由它签发。
+// SHA-256 指纹: B0:B1:73:0E:CB:C7:FF:45:05:14:2C:49:F1:29:5E:6E:DA:6B:CA:ED:7E:2C:68:C5:BE:91:B5:A1:10:01:F0:24
+const appleRootCA = `-----BEGIN CERTIFICATE-----
+MIIEuzCCA6OgAwIBAgIBAjANBgkqhkiG9w0BAQUFADBiMQswCQYDVQQGEwJVUzET
+MBEGA1UEChMKQXBwbGUgSW5jLjEmMCQGA1UECxMdQXBwbGUgQ2VydGlmaWNhdGlv
+biBBdXRob3JpdHkxFjAUBgNVBAMTDUFwcGxlIFJvb3QgQ0EwHhcNMDYwNDI1MjE0
+MDM2WhcNMzUwMjA5MjE0MDM2WjBiMQswCQYDVQQGEwJVUzETMBEGA1UEChMKQXBw
+bGUgSW5jLjEmMCQGA1UECxMdQXBwbGUgQ2VydGlmaWNhdGlvbiBBdXRob3JpdHkx
+FjAUBgNVBAMTDUFwcGxlIFJvb3QgQ0EwggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAw
+ggEKAoIBAQDkkakJH5HbHkdQ6wXtXnmELes2oldMVeyLGYne+Uts9QerIjAC6Bg+
++FAJ039BqJj50cpmnCRrEdCju+QbKsMflZ56DKRHi1vUFjczy8QPTc4UadHJGXL1
+XQ7Vf1+b8iUDulWPTV0N8WQ1IxVLFVkds5T39pyez1C6wVhQZ48ItCD3y6wsIG9w
+tj8BMIy3Q88PnT3zK0koGsj+zrW5DtleHNbLPbU6rfQPDgCSC7EhFi501TwN22IW
+q6NxkkdTVcGvL0Gz+PvjcM3mo0xFfh9Ma1CWQYnEdGILEINBhzOKgbEwWOxaBDKM
+aLOPHd5lc/9nXmW8Sdh2nzMUZaF3lMktAgMBAAGjggF6MIIBdjAOBgNVHQ8BAf8E
+BAMCAQYwDwYDVR0TAQH/BAUwAwEB/zAdBgNVHQ4EFgQUK9BpR5R2Cf70a40uQKb3
+R01/CF4wHwYDVR0jBBgwFoAUK9BpR5R2Cf70a40uQKb3R01/CF4wggERBgNVHSAE
+ggEIMIIBBDCCAQAGCSqGSIb3Y2QFATCB8jAqBggrBgEFBQcCARYeaHR0cHM6Ly93
+d3cuYXBwbGUuY29tL2FwcGxlY2EvMIHDBggrBgEFBQcCAjCBthqBs1JlbGlhbmNl
+IG9uIHRoaXMgY2VydGlmaWNhdGUgYnkgYW55IHBhcnR5IGFzc3VtZXMgYWNjZXB0
+YW5jZSBvZiB0aGUgdGhlbiBhcHBsaWNhYmxlIHN0YW5kYXJkIHRlcm1zIGFuZCBj
+b25kaXRpb25zIG9mIHVzZSwgY2VydGlmaWNhdGUgcG9saWN5IGFuZCBjZXJ0aWZp
+Y2F0aW9uIHByYWN0aWNlIHN0YXRlbWVudHMuMA0GCSqGSIb3DQEBBQUAA4IBAQBc
+NplMLXi37Yyb3PN3m/J20ncwT8EfhYOFG5k9RzfyqZtAjizUsZAS2L70c5vu0mQP
+y3lPNNiiPvl4/2vIB+x9OYOLUyDTOMSxv5pPCmv/K/xZpwUJfBdAVhEedNO3iyM7
+R6PVbyTi69G3cN8PReEnyvFteO3ntRcXqNx+IjXKJdXZD9Zr1KIkIxH3oayPc4Fg
+xhtbCS+SsvhESPBgOJ4V9T0mZyCKM2r3DYLP3uujL/lTaltkwGMzd/c6ByxW69oP
+IQ7aunMZT7XZNn/Bh1XZp5m5MkL72NVxnn6hUrcbvZNCJBIqxw8dtk2cXmPIS4AX
+UKqK1drk/NAJBzewdXUh
+-----END CERTIFICATE-----`
+
+var (
+	// oidAppleIntermediateMarker 中间证书（Apple Worldwide Developer Relations CA）上的 Apple 标记扩展
+	oidAppleIntermediateMarker = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 2, 1}
+	// oidAppleLeafMarker 叶子证书（App Store 签名证书）上的 Apple 标记扩展
+	oidAppleLeafMarker = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 11, 1}
+)
+
+// ErrInvalidCertificateChain JWS 头部中的 x5c 证书链无法通过校验
+var ErrInvalidCertificateChain = errors.New("invalid x5c certificate chain")
+
+// appleRootCertificates 内置的 Apple 根证书
+var appleRootCertificates = mustParseCertificates(appleRootCAG3)
+
+// appleReceiptRootCertificates 内置的签发应用收据的 Apple 根证书
+var appleReceiptRootCertificates = mustParseCertificates(appleRootCA)
+
+func mustParseCertificates(pems ...string) []*x509.Certificate {
+	certs := make([]*x509.Certificate, 0, len(pems))
+	for _, p := range pems {
+		block, _ := pem.Decode([]byte(p))
+		if block == nil {
+			panic("apple: invalid embedded root certificate")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			panic(fmt.Sprintf("apple: invalid embedded root certificate: %v", err))
+		}
+		certs = append(certs, cert)
+	}
+	return certs
+}
+
+// TrustStore 校验 App Store 签名数据时信任的根证书集合，可以并发使用
+type TrustStore struct {
+	mu    sync.RWMutex
+	certs []*x509.Certificate
+	pool  *x509.CertPool
+	ocsp  *OCSPChecker
+}
+
+// NewTrustStore 创建只信任 roots 的信任库，一般用于测试时注入自签的根证书
+func NewTrustStore(roots ...*x509.Certificate) *TrustStore {
+	s := &TrustStore{pool: x509.NewCertPool()}
+	for _, cert := range roots {
+		s.AddCertificate(cert)
+	}
+	return s
+}
+
+// DefaultTrustStore 创建信任内置 Apple 根证书（Apple Root CA - G3）的信任库
+func DefaultTrustStore() *TrustStore {
+	return NewTrustStore(appleRootCertificates...)
+}
+
+// ReceiptTrustStore 创建信任内置 Apple 根证书（Apple Root CA）的信任库，用于校验应用收据
+func ReceiptTrustStore() *TrustStore {
+	return NewTrustStore(appleReceiptRootCertificates...)
+}
+
+// AddCertificate 追加一个受信任的根证书，例如 Apple 轮换根证书后追加新的根证书
+func (s *TrustStore) AddCertificate(cert *x509.Certificate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.certs = append(s.certs, cert)
+	s.pool.AddCert(cert)
+}
+
+// AppendPEM 追加 PEM 格式（可以包含多个 CERTIFICATE 块）的受信任根证书
+func (s *TrustStore) AppendPEM(pemCerts []byte) error {
+	var n int
+	for {
+		var block *pem.Block
+		block, pemCerts = pem.Decode(pemCerts)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse root certificate: %v", err)
+		}
+		s.AddCertificate(cert)
+		n++
+	}
+	if n == 0 {
+		return errors.New("no certificate found in PEM data")
+	}
+	return nil
+}
+
+// EnableOnlineChecks 开启 OCSP 在线吊销检查：校验证书链后再检查中间证书和叶子证书是否已被吊销。
+// 开启后证书有效期以当前时间而不是 signedDate 为准；传入 nil 关闭在线检查
+func (s *TrustStore) EnableOnlineChecks(checker *OCSPChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ocsp = checker
+}
+
+// Certificates 返回信任库中的全部根证书
+func (s *TrustStore) Certificates() []*x509.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*x509.Certificate(nil), s.certs...)
+}
+
+// snapshot 返回用于 x509.Verify 的证书池和 OCSP 检查器；传入 nil 时使用内置 Apple 根证书
+func (s *TrustStore) snapshot() (*x509.CertPool, *OCSPChecker) {
+	if s == nil {
+		return defaultTrustStore.snapshot()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pool.Clone(), s.ocsp
+}
+
+// defaultTrustStore VerifyJWSTransaction 等函数未传入信任库时使用
+var defaultTrustStore = DefaultTrustStore()
+
+// defaultReceiptTrustStore VerifyAppReceipt 未传入信任库时使用
+var defaultReceiptTrustStore = ReceiptTrustStore()
+
+// parseX5C 从 JWS 头部取出 x5c 证书链（叶子、中间、根），每一项都是 DER 证书的标准 Base64 编码
+func parseX5C(header map[string]interface{}) ([]*x509.Certificate, error) {
+	raw, ok := header["x5c"].([]interface{})
+	if !ok || len(raw) != 3 {
+		return nil, fmt.Errorf("%w: x5c header must contain exactly 3 certificates", ErrInvalidCertificateChain)
+	}
+
+	certs := make([]*x509.Certificate, 0, len(raw))
+	for i, item := range raw {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: x5c[%d] is not a string", ErrInvalidCertificateChain, i)
+		}
+		der, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to decode x5c[%d]: %v", ErrInvalidCertificateChain, i, err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to parse x5c[%d]: %v", ErrInvalidCertificateChain, i, err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// verifyCertificateChain 校验 JWS 的 叶子 -> 中间 -> 根 证书链，通过后返回叶子证书的 ECDSA 公钥
+func (s *TrustStore) verifyCertificateChain(chain []*x509.Certificate, at time.Time) (*ecdsa.PublicKey, error) {
+	leaf := chain[0]
+	if err := s.verifyLeaf(leaf, chain[1], at); err != nil {
+		return nil, err
+	}
+
+	pubKey, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: leaf certificate does not hold an ECDSA public key", ErrInvalidCertificateChain)
+	}
+	return pubKey, nil
+}
+
+// verifyLeaf 校验叶子证书经由中间证书链接到受信任的根证书，并在 at 时刻检查证书有效期，
+// 开启在线检查时还会通过 OCSP 检查中间证书和叶子证书的吊销状态
+func (s *TrustStore) verifyLeaf(leaf, intermediate *x509.Certificate, at time.Time) error {
+	if !hasExtension(leaf, oidAppleLeafMarker) {
+		return fmt.Errorf("%w: leaf certificate is missing the Apple marker extension", ErrInvalidCertificateChain)
+	}
+	if !hasExtension(intermediate, oidAppleIntermediateMarker) {
+		return fmt.Errorf("%w: intermediate certificate is missing the Apple marker extension", ErrInvalidCertificateChain)
+	}
+
+	roots, checker := s.snapshot()
+	if checker != nil {
+		at = checker.now()
+	}
+
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intermediate)
+	verified, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCertificateChain, err)
+	}
+
+	if checker != nil {
+		// verified[0] 为 叶子、中间、受信任的根
+		path := verified[0]
+		if len(path) != 3 {
+			return fmt.Errorf("%w: unexpected chain length %d", ErrInvalidCertificateChain, len(path))
+		}
+		if err = checker.Check(path[1], path[2]); err != nil {
+			return err
+		}
+		if err = checker.Check(path[0], path[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
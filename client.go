@@ -1,12 +1,15 @@
 package apple
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/zeromicro/go-zero/core/logx"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
@@ -30,9 +33,37 @@ type Client struct {
 	method        string // 请求方式
 	payload       io.Reader
 	Authorization *string
+	HTTPClient    *http.Client        // 为空时使用 http.DefaultClient
+	Verifier      *SignedDataVerifier // 用于校验并解码接口返回的签名数据
+	// ExtendRequests 保存尚未完成的延期请求的 requestIdentifier，为空时不保存
+	ExtendRequests ExtendRequestStore
+	// MassExtensionAuditor 记录批量延期的审计记录，为空时写入日志
+	MassExtensionAuditor MassExtensionAuditor
 }
 
-func convertToQueryParam(arr []int, key string) string {
+// ErrNoVerifier Client 未设置 Verifier，无法校验接口返回的签名数据
+var ErrNoVerifier = errors.New("client has no SignedDataVerifier")
+
+// APIError App Store Server API 返回的错误
+type APIError struct {
+	StatusCode   int    `json:"-"`            // HTTP 状态码
+	ErrorCode    int64  `json:"errorCode"`    // Apple 定义的错误码，例如 4040010 表示找不到交易
+	ErrorMessage string `json:"errorMessage"` // 错误描述
+}
+
+func (e *APIError) Error() string {
+	if e.ErrorCode == 0 {
+		return fmt.Sprintf("app store server api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("app store server api: %d %s (%d)", e.StatusCode, e.ErrorMessage, e.ErrorCode)
+}
+
+// Retryable 是否可以稍后重试（限流或 Apple 服务端错误）
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+func convertToQueryParam(arr []SubscriptionStatus, key string) string {
 	// 创建一个字符串切片，用于存储拼接后的 key=value
 	var params []string
 	for _, val := range arr {
@@ -45,7 +76,7 @@ func convertToQueryParam(arr []int, key string) string {
 // Subscriptions 查询订阅信息:
 // transactionId 交易ID
 // status 为状态查询参数指定多个值，以获取包含状态与任何值匹配的订阅的响应。 例如，请求返回处于活动状态的订阅（状态值为 1）和处于计费宽限期的订阅（状态值为 4）
-func (c *Client) Subscriptions(transactionId string, status ...int) *Client {
+func (c *Client) Subscriptions(transactionId string, status ...SubscriptionStatus) *Client {
 	state := ""
 	if len(status) > 0 {
 		state = "?" + convertToQueryParam(status, "status")
@@ -60,50 +91,117 @@ func (c *Client) Subscriptions(transactionId string, status ...int) *Client {
 }
 
 func (c *Client) Do() (*StatusResponse, error) {
-	// 处理 Authorization
-	if c.Authorization == nil {
-		jwt, err := GenerateAuthorizationJWT(c.Config.Kid, c.Config.Bid, c.Config.Iss, c.Config.PrivateKey)
+	response := &StatusResponse{}
+	if err := c.send(context.Background(), c.method, c.url, c.payload, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetAllSubscriptionStatuses 查询客户全部订阅的状态（Get All Subscription Statuses），与 Subscriptions(...).Do() 相同，
+// 但不修改 Client，可以并发调用：
+// transactionId 客户任意一笔交易的交易ID
+// status 只返回这些状态的订阅，为空时返回全部
+func (c *Client) GetAllSubscriptionStatuses(ctx context.Context, transactionId string, status ...SubscriptionStatus) (*StatusResponse, error) {
+	endpoint := c.endpoint("/inApps/v1/subscriptions/%s", url.PathEscape(transactionId))
+	if len(status) > 0 {
+		endpoint += "?" + convertToQueryParam(status, "status")
+	}
+	response := &StatusResponse{}
+	if err := c.send(ctx, http.MethodGet, endpoint, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// endpoint 根据 Config.Sandbox 拼接接口地址
+func (c *Client) endpoint(format string, a ...any) string {
+	base := BaseURL
+	if c.Config.Sandbox {
+		base = SandboxURL
+	}
+	return base + fmt.Sprintf(format, a...)
+}
+
+// authorization 返回请求使用的 JWT；未显式设置 Authorization 时每次生成新的（有效期 30 分钟）
+func (c *Client) authorization() (string, error) {
+	if c.Authorization != nil {
+		return *c.Authorization, nil
+	}
+	return GenerateAuthorizationJWT(c.Config.Kid, c.Config.Bid, c.Config.Iss, c.Config.PrivateKey)
+}
+
+// sendJSON 将 body 编码为 JSON 后发送请求，body 为 nil 时不带请求体
+func (c *Client) sendJSON(ctx context.Context, method, url string, body any, out any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		c.Authorization = &jwt
+		payload = bytes.NewReader(data)
 	}
+	return c.send(ctx, method, url, payload, out)
+}
 
-	logx.Debugf("method: %s, url: %s, payload: %s", c.method, c.url, c.payload)
+// send 发送请求，2xx 响应的 JSON 解析到 out（out 为 nil 时忽略响应体），其他响应返回 *APIError
+func (c *Client) send(ctx context.Context, method, url string, payload io.Reader, out any) error {
+	// 处理 Authorization
+	token, err := c.authorization()
+	if err != nil {
+		return err
+	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(c.method, c.url, c.payload)
+	logx.Debugf("method: %s, url: %s", method, url)
 
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", *c.Authorization))
 
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
 	res, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return nil, errors.New(res.Status)
-	}
-
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	response := &StatusResponse{}
-	if err = json.Unmarshal(body, response); err != nil {
-		return nil, err
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: res.StatusCode}
+		// 错误响应体不是 JSON 时只保留状态码
+		_ = json.Unmarshal(body, apiErr)
+		return apiErr
 	}
 
-	return response, nil
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+// verifier 返回用于解码签名数据的 Verifier
+func (c *Client) verifier() (*SignedDataVerifier, error) {
+	if c.Verifier == nil {
+		return nil, ErrNoVerifier
+	}
+	return c.Verifier, nil
 }
 
 func NewClient(config *Config) *Client {
 	return &Client{
-		Config: config,
+		Config:         config,
+		ExtendRequests: NewMemoryExtendRequestStore(0),
 	}
 }
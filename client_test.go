@@ -0,0 +1,99 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient 返回请求发往 handler 的沙箱环境 Client，Verifier 信任 p 的根证书
+func newTestClient(t *testing.T, p *testPKI, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	sandboxURL := SandboxURL
+	SandboxURL = server.URL
+	t.Cleanup(func() {
+		SandboxURL = sandboxURL
+		server.Close()
+	})
+
+	config := &Config{Sandbox: true, Bid: "com.example"}
+	verifier, err := NewSignedDataVerifier(config, 0, NewTrustStore(p.root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(config)
+	authorization := "test"
+	client.Authorization = &authorization
+	client.Verifier = verifier
+	return client
+}
+
+func TestClientAPIError(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantCode      int64
+		wantRetryable bool
+	}{
+		{name: "not found", status: http.StatusNotFound, body: `{"errorCode":4040010,"errorMessage":"Transaction id not found."}`, wantCode: 4040010},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{"errorCode":4290000,"errorMessage":"Rate limit exceeded."}`, wantCode: 4290000, wantRetryable: true},
+		{name: "server error without body", status: http.StatusServiceUnavailable, wantRetryable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, newTestPKI(t, ""), func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer test" {
+					t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			_, err := client.GetTransactionInfo(context.Background(), "1000000000000001")
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.ErrorCode != tt.wantCode || apiErr.Retryable() != tt.wantRetryable {
+				t.Fatalf("APIError = %+v (retryable %v), want status %d code %d retryable %v",
+					apiErr, apiErr.Retryable(), tt.status, tt.wantCode, tt.wantRetryable)
+			}
+		})
+	}
+}
+
+func TestGetAllSubscriptionStatuses(t *testing.T) {
+	// App Store 返回的 lastTransactions 是数组，appAppleId 是数字
+	client := newTestClient(t, newTestPKI(t, ""), func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/inApps/v1/subscriptions/1000000000000001" || r.URL.RawQuery != "status=1&status=4" {
+			t.Errorf("request = %s", r.URL)
+		}
+		w.Write([]byte(`{
+			"environment": "Sandbox",
+			"appAppleId": 1234567890,
+			"bundleId": "com.example",
+			"data": [{
+				"subscriptionGroupIdentifier": "21000000",
+				"lastTransactions": [
+					{"originalTransactionId": "1000000000000001", "status": 1, "signedTransactionInfo": "a", "signedRenewalInfo": "b"},
+					{"originalTransactionId": "1000000000000009", "status": 4, "signedTransactionInfo": "c", "signedRenewalInfo": "d"}
+				]
+			}]
+		}`))
+	})
+	response, err := client.GetAllSubscriptionStatuses(context.Background(), "1000000000000001", SubscriptionStatusActive, SubscriptionStatusBillingGracePeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.AppAppleId != 1234567890 || response.Environment != EnvironmentSandbox || len(response.Data) != 1 {
+		t.Fatalf("response = %+v", response)
+	}
+	items := response.Data[0].LastTransactions
+	if len(items) != 2 || items[1].OriginalTransactionId != "1000000000000009" || items[1].Status != SubscriptionStatusBillingGracePeriod {
+		t.Fatalf("lastTransactions = %+v", items)
+	}
+}
@@ -0,0 +1,310 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ConsumptionStatus 客户对应用内购买的消耗程度
+type ConsumptionStatus int32
+
+const (
+	ConsumptionStatusUndeclared        ConsumptionStatus = 0 // 未声明
+	ConsumptionStatusNotConsumed       ConsumptionStatus = 1 // 未消耗
+	ConsumptionStatusPartiallyConsumed ConsumptionStatus = 2 // 部分消耗
+	ConsumptionStatusFullyConsumed     ConsumptionStatus = 3 // 完全消耗
+)
+
+var consumptionStatusNames = map[ConsumptionStatus]string{
+	ConsumptionStatusUndeclared:        "UNDECLARED",
+	ConsumptionStatusNotConsumed:       "NOT_CONSUMED",
+	ConsumptionStatusPartiallyConsumed: "PARTIALLY_CONSUMED",
+	ConsumptionStatusFullyConsumed:     "FULLY_CONSUMED",
+}
+
+func (s ConsumptionStatus) String() string {
+	return enumString(consumptionStatusNames, s, "ConsumptionStatus")
+}
+
+// IsValid 是否为已知的消耗程度
+func (s ConsumptionStatus) IsValid() bool {
+	_, ok := consumptionStatusNames[s]
+	return ok
+}
+
+// Platform 客户使用的平台
+type Platform int32
+
+const (
+	PlatformUndeclared Platform = 0 // 未声明
+	PlatformApple      Platform = 1 // Apple 平台
+	PlatformNonApple   Platform = 2 // 非 Apple 平台
+)
+
+var platformNames = map[Platform]string{
+	PlatformUndeclared: "UNDECLARED",
+	PlatformApple:      "APPLE",
+	PlatformNonApple:   "NON_APPLE",
+}
+
+func (p Platform) String() string {
+	return enumString(platformNames, p, "Platform")
+}
+
+// IsValid 是否为已知的平台
+func (p Platform) IsValid() bool {
+	_, ok := platformNames[p]
+	return ok
+}
+
+// DeliveryStatus 应用内购买是否已正常交付
+type DeliveryStatus int32
+
+const (
+	DeliveryStatusDeliveredAndWorkingProperly DeliveryStatus = 0 // 已交付且工作正常
+	DeliveryStatusDidNotDeliverDueToQuality   DeliveryStatus = 1 // 因质量问题未交付
+	DeliveryStatusDeliveredWrongItem          DeliveryStatus = 2 // 交付了错误的项目
+	DeliveryStatusDidNotDeliverDueToOutage    DeliveryStatus = 3 // 因服务中断未交付
+	DeliveryStatusDidNotDeliverDueToCurrency  DeliveryStatus = 4 // 因游戏内货币变化未交付
+	DeliveryStatusDidNotDeliverForOtherReason DeliveryStatus = 5 // 因其他原因未交付
+)
+
+var deliveryStatusNames = map[DeliveryStatus]string{
+	DeliveryStatusDeliveredAndWorkingProperly: "DELIVERED_AND_WORKING_PROPERLY",
+	DeliveryStatusDidNotDeliverDueToQuality:   "DID_NOT_DELIVER_DUE_TO_QUALITY_ISSUE",
+	DeliveryStatusDeliveredWrongItem:          "DELIVERED_WRONG_ITEM",
+	DeliveryStatusDidNotDeliverDueToOutage:    "DID_NOT_DELIVER_DUE_TO_SERVER_OUTAGE",
+	DeliveryStatusDidNotDeliverDueToCurrency:  "DID_NOT_DELIVER_DUE_TO_IN_GAME_CURRENCY_CHANGE",
+	DeliveryStatusDidNotDeliverForOtherReason: "DID_NOT_DELIVER_FOR_OTHER_REASON",
+}
+
+func (s DeliveryStatus) String() string {
+	return enumString(deliveryStatusNames, s, "DeliveryStatus")
+}
+
+// IsValid 是否为已知的交付状态
+func (s DeliveryStatus) IsValid() bool {
+	_, ok := deliveryStatusNames[s]
+	return ok
+}
+
+// AccountTenure 客户账户的注册时长
+type AccountTenure int32
+
+const (
+	AccountTenureUndeclared                AccountTenure = 0 // 未声明
+	AccountTenureZeroToThreeDays           AccountTenure = 1 // 0 到 3 天
+	AccountTenureThreeDaysToTenDays        AccountTenure = 2 // 3 到 10 天
+	AccountTenureTenDaysToThirtyDays       AccountTenure = 3 // 10 到 30 天
+	AccountTenureThirtyDaysToNinetyDays    AccountTenure = 4 // 30 到 90 天
+	AccountTenureNinetyDaysToOneEightyDays AccountTenure = 5 // 90 到 180 天
+	AccountTenureOneEightyDaysToOneYear    AccountTenure = 6 // 180 到 365 天
+	AccountTenureGreaterThanOneYear        AccountTenure = 7 // 超过 365 天
+)
+
+var accountTenureNames = map[AccountTenure]string{
+	AccountTenureUndeclared:                "UNDECLARED",
+	AccountTenureZeroToThreeDays:           "ZERO_TO_THREE_DAYS",
+	AccountTenureThreeDaysToTenDays:        "THREE_DAYS_TO_TEN_DAYS",
+	AccountTenureTenDaysToThirtyDays:       "TEN_DAYS_TO_THIRTY_DAYS",
+	AccountTenureThirtyDaysToNinetyDays:    "THIRTY_DAYS_TO_NINETY_DAYS",
+	AccountTenureNinetyDaysToOneEightyDays: "NINETY_DAYS_TO_ONE_HUNDRED_EIGHTY_DAYS",
+	AccountTenureOneEightyDaysToOneYear:    "ONE_HUNDRED_EIGHTY_DAYS_TO_THREE_HUNDRED_SIXTY_FIVE_DAYS",
+	AccountTenureGreaterThanOneYear:        "GREATER_THAN_THREE_HUNDRED_SIXTY_FIVE_DAYS",
+}
+
+func (t AccountTenure) String() string {
+	return enumString(accountTenureNames, t, "AccountTenure")
+}
+
+// IsValid 是否为已知的账户时长
+func (t AccountTenure) IsValid() bool {
+	_, ok := accountTenureNames[t]
+	return ok
+}
+
+// PlayTime 客户使用应用的总时长
+type PlayTime int32
+
+const (
+	PlayTimeUndeclared            PlayTime = 0 // 未声明
+	PlayTimeZeroToFiveMinutes     PlayTime = 1 // 0 到 5 分钟
+	PlayTimeFiveToSixtyMinutes    PlayTime = 2 // 5 到 60 分钟
+	PlayTimeOneToSixHours         PlayTime = 3 // 1 到 6 小时
+	PlayTimeSixHoursToTwentyFour  PlayTime = 4 // 6 到 24 小时
+	PlayTimeOneDayToFourDays      PlayTime = 5 // 1 到 4 天
+	PlayTimeFourDaysToSixteenDays PlayTime = 6 // 4 到 16 天
+	PlayTimeOverSixteenDays       PlayTime = 7 // 超过 16 天
+)
+
+var playTimeNames = map[PlayTime]string{
+	PlayTimeUndeclared:            "UNDECLARED",
+	PlayTimeZeroToFiveMinutes:     "ZERO_TO_FIVE_MINUTES",
+	PlayTimeFiveToSixtyMinutes:    "FIVE_TO_SIXTY_MINUTES",
+	PlayTimeOneToSixHours:         "ONE_TO_SIX_HOURS",
+	PlayTimeSixHoursToTwentyFour:  "SIX_HOURS_TO_TWENTY_FOUR_HOURS",
+	PlayTimeOneDayToFourDays:      "ONE_DAY_TO_FOUR_DAYS",
+	PlayTimeFourDaysToSixteenDays: "FOUR_DAYS_TO_SIXTEEN_DAYS",
+	PlayTimeOverSixteenDays:       "OVER_SIXTEEN_DAYS",
+}
+
+func (t PlayTime) String() string {
+	return enumString(playTimeNames, t, "PlayTime")
+}
+
+// IsValid 是否为已知的使用时长
+func (t PlayTime) IsValid() bool {
+	_, ok := playTimeNames[t]
+	return ok
+}
+
+// LifetimeDollars 客户在所有平台上累计购买或退款的金额区间（美元）
+type LifetimeDollars int32
+
+const (
+	LifetimeDollarsUndeclared                  LifetimeDollars = 0 // 未声明
+	LifetimeDollarsZero                        LifetimeDollars = 1 // 0 美元
+	LifetimeDollarsOneCentToFortyNine          LifetimeDollars = 2 // 0.01 到 49.99 美元
+	LifetimeDollarsFiftyToNinetyNine           LifetimeDollars = 3 // 50 到 99.99 美元
+	LifetimeDollarsOneHundredToFourNinetyNine  LifetimeDollars = 4 // 100 到 499.99 美元
+	LifetimeDollarsFiveHundredToNineNinetyNine LifetimeDollars = 5 // 500 到 999.99 美元
+	LifetimeDollarsOneThousandToOneNineNine    LifetimeDollars = 6 // 1000 到 1999.99 美元
+	LifetimeDollarsTwoThousandOrGreater        LifetimeDollars = 7 // 2000 美元以上
+)
+
+var lifetimeDollarsNames = map[LifetimeDollars]string{
+	LifetimeDollarsUndeclared:                  "UNDECLARED",
+	LifetimeDollarsZero:                        "ZERO_DOLLARS",
+	LifetimeDollarsOneCentToFortyNine:          "ONE_CENT_TO_FORTY_NINE_DOLLARS_AND_NINETY_NINE_CENTS",
+	LifetimeDollarsFiftyToNinetyNine:           "FIFTY_DOLLARS_TO_NINETY_NINE_DOLLARS_AND_NINETY_NINE_CENTS",
+	LifetimeDollarsOneHundredToFourNinetyNine:  "ONE_HUNDRED_DOLLARS_TO_FOUR_HUNDRED_NINETY_NINE_DOLLARS_AND_NINETY_NINE_CENTS",
+	LifetimeDollarsFiveHundredToNineNinetyNine: "FIVE_HUNDRED_DOLLARS_TO_NINE_HUNDRED_NINETY_NINE_DOLLARS_AND_NINETY_NINE_CENTS",
+	LifetimeDollarsOneThousandToOneNineNine:    "ONE_THOUSAND_DOLLARS_TO_ONE_THOUSAND_NINE_HUNDRED_NINETY_NINE_DOLLARS_AND_NINETY_NINE_CENTS",
+	LifetimeDollarsTwoThousandOrGreater:        "TWO_THOUSAND_DOLLARS_OR_GREATER",
+}
+
+func (d LifetimeDollars) String() string {
+	return enumString(lifetimeDollarsNames, d, "LifetimeDollars")
+}
+
+// IsValid 是否为已知的金额区间
+func (d LifetimeDollars) IsValid() bool {
+	_, ok := lifetimeDollarsNames[d]
+	return ok
+}
+
+// UserStatus 客户账户的状态
+type UserStatus int32
+
+const (
+	UserStatusUndeclared    UserStatus = 0 // 未声明
+	UserStatusActive        UserStatus = 1 // 正常
+	UserStatusSuspended     UserStatus = 2 // 已暂停
+	UserStatusTerminated    UserStatus = 3 // 已注销
+	UserStatusLimitedAccess UserStatus = 4 // 受限访问
+)
+
+var userStatusNames = map[UserStatus]string{
+	UserStatusUndeclared:    "UNDECLARED",
+	UserStatusActive:        "ACTIVE",
+	UserStatusSuspended:     "SUSPENDED",
+	UserStatusTerminated:    "TERMINATED",
+	UserStatusLimitedAccess: "LIMITED_ACCESS",
+}
+
+func (s UserStatus) String() string {
+	return enumString(userStatusNames, s, "UserStatus")
+}
+
+// IsValid 是否为已知的账户状态
+func (s UserStatus) IsValid() bool {
+	_, ok := userStatusNames[s]
+	return ok
+}
+
+// RefundPreference 你希望 App Store 如何处理此次退款申请
+type RefundPreference int32
+
+const (
+	RefundPreferenceUndeclared    RefundPreference = 0 // 未声明
+	RefundPreferencePreferGrant   RefundPreference = 1 // 倾向于同意退款
+	RefundPreferencePreferDecline RefundPreference = 2 // 倾向于拒绝退款
+	RefundPreferenceNoPreference  RefundPreference = 3 // 没有倾向
+)
+
+var refundPreferenceNames = map[RefundPreference]string{
+	RefundPreferenceUndeclared:    "UNDECLARED",
+	RefundPreferencePreferGrant:   "PREFER_GRANT",
+	RefundPreferencePreferDecline: "PREFER_DECLINE",
+	RefundPreferenceNoPreference:  "NO_PREFERENCE",
+}
+
+func (p RefundPreference) String() string {
+	return enumString(refundPreferenceNames, p, "RefundPreference")
+}
+
+// IsValid 是否为已知的退款倾向
+func (p RefundPreference) IsValid() bool {
+	_, ok := refundPreferenceNames[p]
+	return ok
+}
+
+// ConsumptionRequest 回应 CONSUMPTION_REQUEST 通知时发送的消耗信息
+type ConsumptionRequest struct {
+	CustomerConsented        bool              `json:"customerConsented"`        // 客户是否同意提供消耗数据，必须为 true。
+	ConsumptionStatus        ConsumptionStatus `json:"consumptionStatus"`        // 客户对应用内购买的消耗程度。
+	Platform                 Platform          `json:"platform"`                 // 客户使用的平台。
+	SampleContentProvided    bool              `json:"sampleContentProvided"`    // 购买前是否提供了免费试用或示例内容。
+	DeliveryStatus           DeliveryStatus    `json:"deliveryStatus"`           // 应用内购买是否已正常交付。
+	AppAccountToken          string            `json:"appAccountToken"`          // 购买时关联的客户 UUID，没有时为空字符串。
+	AccountTenure            AccountTenure     `json:"accountTenure"`            // 客户账户的注册时长。
+	PlayTime                 PlayTime          `json:"playTime"`                 // 客户使用应用的总时长。
+	LifetimeDollarsRefunded  LifetimeDollars   `json:"lifetimeDollarsRefunded"`  // 客户累计退款的金额区间。
+	LifetimeDollarsPurchased LifetimeDollars   `json:"lifetimeDollarsPurchased"` // 客户累计购买的金额区间。
+	UserStatus               UserStatus        `json:"userStatus"`               // 客户账户的状态。
+	RefundPreference         RefundPreference  `json:"refundPreference"`         // 你希望 App Store 如何处理此次退款申请。
+}
+
+// Validate 检查消耗信息，发送前会自动调用
+func (r *ConsumptionRequest) Validate() error {
+	if !r.CustomerConsented {
+		return errors.New("customerConsented must be true")
+	}
+	if r.AppAccountToken != "" && !isUUID(r.AppAccountToken) {
+		return fmt.Errorf("appAccountToken %q is not a UUID", r.AppAccountToken)
+	}
+	checks := []struct {
+		name  string
+		valid bool
+		value fmt.Stringer
+	}{
+		{"consumptionStatus", r.ConsumptionStatus.IsValid(), r.ConsumptionStatus},
+		{"platform", r.Platform.IsValid(), r.Platform},
+		{"deliveryStatus", r.DeliveryStatus.IsValid(), r.DeliveryStatus},
+		{"accountTenure", r.AccountTenure.IsValid(), r.AccountTenure},
+		{"playTime", r.PlayTime.IsValid(), r.PlayTime},
+		{"lifetimeDollarsRefunded", r.LifetimeDollarsRefunded.IsValid(), r.LifetimeDollarsRefunded},
+		{"lifetimeDollarsPurchased", r.LifetimeDollarsPurchased.IsValid(), r.LifetimeDollarsPurchased},
+		{"userStatus", r.UserStatus.IsValid(), r.UserStatus},
+		{"refundPreference", r.RefundPreference.IsValid(), r.RefundPreference},
+	}
+	for _, check := range checks {
+		if !check.valid {
+			return fmt.Errorf("invalid %s %s", check.name, check.value)
+		}
+	}
+	return nil
+}
+
+// SendConsumptionInformation 发送客户的消耗信息以回应 CONSUMPTION_REQUEST 通知（Send Consumption Information）：
+// transactionId 通知中申请退款的交易ID
+// 需要在收到通知后 12 小时内发送
+func (c *Client) SendConsumptionInformation(ctx context.Context, transactionId string, request *ConsumptionRequest) error {
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	return c.sendJSON(ctx, http.MethodPut, c.endpoint("/inApps/v1/transactions/consumption/%s", url.PathEscape(transactionId)), request, nil)
+}
@@ -0,0 +1,159 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zeromicro/go-zero/core/logx"
+	"time"
+)
+
+// ConsumptionResponseWindow Apple 要求在收到 CONSUMPTION_REQUEST 通知后多久内发送消耗信息
+const ConsumptionResponseWindow = 12 * time.Hour
+
+// ErrInvalidConsumptionData Provider 返回的消耗信息无法通过校验，重试也不会成功
+var ErrInvalidConsumptionData = errors.New("invalid consumption data")
+
+// ConsumptionDataProvider 为申请退款的交易提供消耗信息，一般从业务系统中查询客户的使用数据
+type ConsumptionDataProvider interface {
+	ConsumptionData(ctx context.Context, transaction *JWSTransactionDecodedPayload, reason ConsumptionRequestReason) (*ConsumptionRequest, error)
+}
+
+// ConsumptionDeadlineError 未能在截止时间前发送消耗信息
+type ConsumptionDeadlineError struct {
+	TransactionId string    // 申请退款的交易ID
+	Deadline      time.Time // 截止时间
+	Attempts      int       // 已尝试的次数
+	Err           error     // 最后一次失败的原因，没有尝试时为 nil
+}
+
+func (e *ConsumptionDeadlineError) Error() string {
+	msg := fmt.Sprintf("missed consumption deadline %s for transaction %s after %d attempts",
+		e.Deadline.Format(time.RFC3339), e.TransactionId, e.Attempts)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ConsumptionDeadlineError) Unwrap() error {
+	return e.Err
+}
+
+// ConsumptionResponder 收到 CONSUMPTION_REQUEST 通知后，通过 Provider 获取消耗信息并发送给 App Store，
+// 失败时按指数退避重试，直到成功、遇到不可重试的错误（包括消耗信息无法通过校验）或超过 12 小时的截止时间
+type ConsumptionResponder struct {
+	Client   *Client
+	Provider ConsumptionDataProvider
+
+	MaxAttempts   int           // 最多尝试的次数，小于等于 0 时不限次数，直到截止时间
+	RetryInterval time.Duration // 第一次重试前等待的时间，之后每次加倍，最长 30 分钟；为 0 时为 30 秒
+
+	// OnDeadlineMissed 未能在截止时间前发送消耗信息时调用，为空时写入日志
+	OnDeadlineMissed func(ctx context.Context, err *ConsumptionDeadlineError)
+}
+
+// NewConsumptionResponder 创建 CONSUMPTION_REQUEST 通知的自动应答器，client 需要设置 Verifier
+func NewConsumptionResponder(client *Client, provider ConsumptionDataProvider) *ConsumptionResponder {
+	return &ConsumptionResponder{
+		Client:   client,
+		Provider: provider,
+	}
+}
+
+// Respond 处理已校验的通知：非 CONSUMPTION_REQUEST 通知直接返回 nil；
+// 否则一直重试到发送成功或截止时间，可能阻塞较长时间，处理 webhook 时应放到后台执行，
+// 以便先向 App Store 返回成功
+func (r *ConsumptionResponder) Respond(ctx context.Context, notification *ResponseBodyV2DecodedPayload) error {
+	if notification.NotificationType != NotificationTypeConsumptionRequest {
+		return nil
+	}
+	if notification.Data == nil || notification.Data.SignedTransactionInfo == "" {
+		return errors.New("consumption request notification has no signedTransactionInfo")
+	}
+	// 截止时间从 signedDate 起算，缺失时无法确定
+	if notification.SignedDate == 0 {
+		return errors.New("consumption request notification has no signedDate")
+	}
+
+	transaction := notification.Data.TransactionInfo
+	if transaction == nil {
+		verifier, err := r.Client.verifier()
+		if err != nil {
+			return err
+		}
+		if transaction, err = verifier.VerifyAndDecodeTransaction(notification.Data.SignedTransactionInfo); err != nil {
+			return err
+		}
+	}
+
+	deadline := notification.SignedDate.Time().Add(ConsumptionResponseWindow)
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	missed := &ConsumptionDeadlineError{TransactionId: transaction.TransactionId, Deadline: deadline}
+	wait := r.RetryInterval
+	if wait <= 0 {
+		wait = 30 * time.Second
+	}
+
+	for {
+		if !time.Now().Before(deadline) {
+			return r.deadlineMissed(ctx, missed)
+		}
+
+		missed.Attempts++
+		err := r.attempt(ctx, transaction, notification.Data.ConsumptionRequestReason)
+		if err == nil {
+			return nil
+		}
+		missed.Err = err
+
+		var apiErr *APIError
+		if errors.Is(err, ErrInvalidConsumptionData) || errors.As(err, &apiErr) && !apiErr.Retryable() {
+			return err
+		}
+		if r.MaxAttempts > 0 && missed.Attempts >= r.MaxAttempts {
+			return err
+		}
+
+		logx.Errorf("failed to send consumption information for transaction %s (attempt %d): %v",
+			transaction.TransactionId, missed.Attempts, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// 调用方的 ctx 先于截止时间结束时不算错过截止时间
+			if !time.Now().Before(deadline) {
+				return r.deadlineMissed(ctx, missed)
+			}
+			return ctx.Err()
+		case <-timer.C:
+		}
+		wait = min(wait*2, 30*time.Minute)
+	}
+}
+
+func (r *ConsumptionResponder) attempt(ctx context.Context, transaction *JWSTransactionDecodedPayload, reason ConsumptionRequestReason) error {
+	request, err := r.Provider.ConsumptionData(ctx, transaction, reason)
+	if err != nil {
+		return fmt.Errorf("failed to get consumption data: %w", err)
+	}
+	if request == nil {
+		return fmt.Errorf("%w: provider returned no consumption data", ErrInvalidConsumptionData)
+	}
+	if err = request.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConsumptionData, err)
+	}
+	return r.Client.SendConsumptionInformation(ctx, transaction.TransactionId, request)
+}
+
+func (r *ConsumptionResponder) deadlineMissed(ctx context.Context, err *ConsumptionDeadlineError) error {
+	if r.OnDeadlineMissed != nil {
+		r.OnDeadlineMissed(context.WithoutCancel(ctx), err)
+	} else {
+		logx.Error(err)
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type consumptionProviderFunc func(ctx context.Context, transaction *JWSTransactionDecodedPayload, reason ConsumptionRequestReason) (*ConsumptionRequest, error)
+
+func (f consumptionProviderFunc) ConsumptionData(ctx context.Context, transaction *JWSTransactionDecodedPayload, reason ConsumptionRequestReason) (*ConsumptionRequest, error) {
+	return f(ctx, transaction, reason)
+}
+
+func newConsumptionRequestNotification(signedDate time.Time) *ResponseBodyV2DecodedPayload {
+	notification := &ResponseBodyV2DecodedPayload{
+		NotificationType: NotificationTypeConsumptionRequest,
+		NotificationUUID: "002e14d5-51f5-4503-b5a8-c3a1af68eb20",
+		Data: &NotificationData{
+			SignedTransactionInfo:    "signed-transaction",
+			TransactionInfo:          &JWSTransactionDecodedPayload{TransactionId: "1000000000000001"},
+			ConsumptionRequestReason: ConsumptionRequestReasonUnintendedPurchase,
+		},
+	}
+	if !signedDate.IsZero() {
+		notification.SignedDate = Timestamp(signedDate.UnixMilli())
+	}
+	return notification
+}
+
+func TestConsumptionResponder(t *testing.T) {
+	valid := &ConsumptionRequest{CustomerConsented: true}
+
+	tests := []struct {
+		name         string
+		signedDate   time.Time
+		data         func(call int) (*ConsumptionRequest, error)
+		statuses     []int // App Store 依次返回的状态码，用完后返回 202
+		timeout      time.Duration
+		maxAttempts  int
+		wantErr      func(err error) bool
+		wantCalls    int
+		wantRequests int
+		wantMissed   bool
+	}{
+		{
+			name:       "sent",
+			signedDate: time.Now(),
+			data:       func(int) (*ConsumptionRequest, error) { return valid, nil },
+			wantErr:    func(err error) bool { return err == nil },
+			wantCalls:  1, wantRequests: 1,
+		},
+		{
+			name:       "nil data is permanent",
+			signedDate: time.Now(),
+			data:       func(int) (*ConsumptionRequest, error) { return nil, nil },
+			wantErr:    func(err error) bool { return errors.Is(err, ErrInvalidConsumptionData) },
+			wantCalls:  1,
+		},
+		{
+			name:       "invalid data is permanent",
+			signedDate: time.Now(),
+			data:       func(int) (*ConsumptionRequest, error) { return &ConsumptionRequest{}, nil },
+			wantErr:    func(err error) bool { return errors.Is(err, ErrInvalidConsumptionData) },
+			wantCalls:  1,
+		},
+		{
+			name:       "provider and app store errors are retried",
+			signedDate: time.Now(),
+			data: func(call int) (*ConsumptionRequest, error) {
+				if call == 1 {
+					return nil, errors.New("database unavailable")
+				}
+				return valid, nil
+			},
+			statuses:  []int{http.StatusInternalServerError},
+			wantErr:   func(err error) bool { return err == nil },
+			wantCalls: 3, wantRequests: 2,
+		},
+		{
+			name:       "client errors are not retried",
+			signedDate: time.Now(),
+			data:       func(int) (*ConsumptionRequest, error) { return valid, nil },
+			statuses:   []int{http.StatusBadRequest},
+			wantErr: func(err error) bool {
+				var apiErr *APIError
+				return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+			},
+			wantCalls: 1, wantRequests: 1,
+		},
+		{
+			name:        "max attempts",
+			signedDate:  time.Now(),
+			data:        func(int) (*ConsumptionRequest, error) { return valid, nil },
+			statuses:    []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			maxAttempts: 2,
+			wantErr: func(err error) bool {
+				var apiErr *APIError
+				return errors.As(err, &apiErr) && apiErr.Retryable()
+			},
+			wantCalls: 2, wantRequests: 2,
+		},
+		{
+			name:       "deadline missed",
+			signedDate: time.Now().Add(-ConsumptionResponseWindow - time.Minute),
+			data:       func(int) (*ConsumptionRequest, error) { return valid, nil },
+			wantErr: func(err error) bool {
+				var missed *ConsumptionDeadlineError
+				return errors.As(err, &missed)
+			},
+			wantMissed: true,
+		},
+		{
+			name:       "caller context ends before the deadline",
+			signedDate: time.Now(),
+			data:       func(int) (*ConsumptionRequest, error) { return nil, errors.New("database unavailable") },
+			timeout:    50 * time.Millisecond,
+			wantErr:    func(err error) bool { return errors.Is(err, context.DeadlineExceeded) },
+			wantCalls:  -1,
+		},
+		{
+			name:    "missing signedDate",
+			data:    func(int) (*ConsumptionRequest, error) { return valid, nil },
+			wantErr: func(err error) bool { return err != nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			client := newTestClient(t, newTestPKI(t, ""), func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				if r.Method != http.MethodPut || r.URL.Path != "/inApps/v1/transactions/consumption/1000000000000001" {
+					t.Errorf("request = %s %s", r.Method, r.URL.Path)
+				}
+				if n <= len(tt.statuses) {
+					w.WriteHeader(tt.statuses[n-1])
+					return
+				}
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			var calls int
+			responder := NewConsumptionResponder(client, consumptionProviderFunc(
+				func(_ context.Context, transaction *JWSTransactionDecodedPayload, reason ConsumptionRequestReason) (*ConsumptionRequest, error) {
+					calls++
+					if reason != ConsumptionRequestReasonUnintendedPurchase {
+						t.Errorf("reason = %s", reason)
+					}
+					return tt.data(calls)
+				}))
+			responder.RetryInterval = time.Millisecond
+			responder.MaxAttempts = tt.maxAttempts
+			var missed bool
+			responder.OnDeadlineMissed = func(context.Context, *ConsumptionDeadlineError) { missed = true }
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			err := responder.Respond(ctx, newConsumptionRequestNotification(tt.signedDate))
+
+			if !tt.wantErr(err) {
+				t.Fatalf("Respond() error = %v", err)
+			}
+			if tt.wantCalls >= 0 && calls != tt.wantCalls {
+				t.Fatalf("provider calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if got := int(requests.Load()); got != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if missed != tt.wantMissed {
+				t.Fatalf("OnDeadlineMissed called = %v, want %v", missed, tt.wantMissed)
+			}
+		})
+	}
+}
+
+func TestConsumptionResponderIgnoresOtherNotifications(t *testing.T) {
+	responder := NewConsumptionResponder(nil, consumptionProviderFunc(
+		func(context.Context, *JWSTransactionDecodedPayload, ConsumptionRequestReason) (*ConsumptionRequest, error) {
+			t.Fatal("provider called for a REFUND notification")
+			return nil, nil
+		}))
+	if err := responder.Respond(context.Background(), &ResponseBodyV2DecodedPayload{NotificationType: NotificationTypeRefund}); err != nil {
+		t.Fatalf("Respond() error = %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package apple
+
+import (
+	"context"
+	"fmt"
+)
+
+// Entitlement 客户在一个订阅组中的权益
+type Entitlement struct {
+	SubscriptionGroupIdentifier string             `json:"subscriptionGroupIdentifier"` // 订阅组的标识符。
+	OriginalTransactionId       string             `json:"originalTransactionId"`       // 订阅的原始交易标识符。
+	ProductId                   string             `json:"productId"`                   // 当前订阅的产品标识符。
+	Status                      SubscriptionStatus `json:"status"`                      // 订阅的状态。
+	ExpiresDate                 Timestamp          `json:"expiresDate"`                 // 当前订阅期到期的 UNIX 时间（以毫秒为单位）。
+	AutoRenewStatus             AutoRenewStatus    `json:"autoRenewStatus"`             // 是否会自动续订。
+	Active                      bool               `json:"active"`                      // 是否享有权益：订阅有效或处于计费宽限期。
+}
+
+// Entitlements 查询客户全部订阅的状态，并通过 Client.Verifier 校验解码每个订阅的最新交易，返回客户的权益：
+// transactionId 客户任意一笔交易的交易ID
+func (c *Client) Entitlements(ctx context.Context, transactionId string) ([]*Entitlement, error) {
+	verifier, err := c.verifier()
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := c.GetAllSubscriptionStatuses(ctx, transactionId)
+	if err != nil {
+		return nil, err
+	}
+
+	var entitlements []*Entitlement
+	for _, group := range statuses.Data {
+		for _, item := range group.LastTransactions {
+			transaction, err := verifier.VerifyAndDecodeTransaction(item.SignedTransactionInfo)
+			if err != nil {
+				return nil, fmt.Errorf("invalid transaction of subscription %s: %w", item.OriginalTransactionId, err)
+			}
+			entitlement := &Entitlement{
+				SubscriptionGroupIdentifier: group.SubscriptionGroupIdentifier,
+				OriginalTransactionId:       item.OriginalTransactionId,
+				ProductId:                   transaction.ProductId,
+				Status:                      item.Status,
+				ExpiresDate:                 transaction.ExpiresDate,
+				Active:                      item.Status == SubscriptionStatusActive || item.Status == SubscriptionStatusBillingGracePeriod,
+			}
+			if item.SignedRenewalInfo != "" {
+				renewalInfo, err := verifier.VerifyAndDecodeRenewalInfo(item.SignedRenewalInfo)
+				if err != nil {
+					return nil, fmt.Errorf("invalid renewal info of subscription %s: %w", item.OriginalTransactionId, err)
+				}
+				entitlement.AutoRenewStatus = renewalInfo.AutoRenewStatus
+			}
+			entitlements = append(entitlements, entitlement)
+		}
+	}
+	return entitlements, nil
+}
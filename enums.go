@@ -0,0 +1,446 @@
+package apple
+
+import (
+	"fmt"
+)
+
+// enumString 返回整数枚举值的名称，未知的值（例如 Apple 以后新增的值）返回 "类型名(值)"
+func enumString[T ~int32](names map[T]string, v T, typeName string) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("%s(%d)", typeName, int32(v))
+}
+
+// Environment 服务器环境
+type Environment string
+
+const (
+	EnvironmentSandbox      Environment = "Sandbox"      // 沙箱环境
+	EnvironmentProduction   Environment = "Production"   // 生产环境
+	EnvironmentXcode        Environment = "Xcode"        // Xcode 中的 StoreKit 测试环境
+	EnvironmentLocalTesting Environment = "LocalTesting" // 本地测试环境
+)
+
+func (e Environment) String() string { return string(e) }
+
+// IsValid 是否为已知的环境
+func (e Environment) IsValid() bool {
+	switch e {
+	case EnvironmentSandbox, EnvironmentProduction, EnvironmentXcode, EnvironmentLocalTesting:
+		return true
+	}
+	return false
+}
+
+// SubscriptionStatus 自动续订订阅的状态
+type SubscriptionStatus int32
+
+const (
+	SubscriptionStatusActive             SubscriptionStatus = 1 // 有效
+	SubscriptionStatusExpired            SubscriptionStatus = 2 // 已过期
+	SubscriptionStatusBillingRetry       SubscriptionStatus = 3 // 处于计费重试期
+	SubscriptionStatusBillingGracePeriod SubscriptionStatus = 4 // 处于计费宽限期
+	SubscriptionStatusRevoked            SubscriptionStatus = 5 // 已撤销（退款或从家庭共享中撤销）
+)
+
+var subscriptionStatusNames = map[SubscriptionStatus]string{
+	SubscriptionStatusActive:             "ACTIVE",
+	SubscriptionStatusExpired:            "EXPIRED",
+	SubscriptionStatusBillingRetry:       "BILLING_RETRY",
+	SubscriptionStatusBillingGracePeriod: "BILLING_GRACE_PERIOD",
+	SubscriptionStatusRevoked:            "REVOKED",
+}
+
+func (s SubscriptionStatus) String() string {
+	return enumString(subscriptionStatusNames, s, "SubscriptionStatus")
+}
+
+// IsValid 是否为已知的订阅状态
+func (s SubscriptionStatus) IsValid() bool {
+	_, ok := subscriptionStatusNames[s]
+	return ok
+}
+
+// AutoRenewStatus 自动续订订阅的续订状态
+type AutoRenewStatus int32
+
+const (
+	AutoRenewStatusOff AutoRenewStatus = 0 // 客户已关闭自动续订，当前订阅期结束后不会续订
+	AutoRenewStatusOn  AutoRenewStatus = 1 // 订阅将在当前订阅期结束时续订
+)
+
+var autoRenewStatusNames = map[AutoRenewStatus]string{
+	AutoRenewStatusOff: "OFF",
+	AutoRenewStatusOn:  "ON",
+}
+
+func (s AutoRenewStatus) String() string {
+	return enumString(autoRenewStatusNames, s, "AutoRenewStatus")
+}
+
+// IsValid 是否为已知的续订状态
+func (s AutoRenewStatus) IsValid() bool {
+	_, ok := autoRenewStatusNames[s]
+	return ok
+}
+
+// ExpirationIntent 订阅过期的原因
+type ExpirationIntent int32
+
+const (
+	ExpirationIntentCustomerCancelled                    ExpirationIntent = 1 // 客户取消了订阅
+	ExpirationIntentBillingError                         ExpirationIntent = 2 // 计费错误，例如客户的付款信息已失效
+	ExpirationIntentCustomerDidNotConsentToPriceIncrease ExpirationIntent = 3 // 客户不同意需要征得同意的涨价
+	ExpirationIntentProductNotAvailable                  ExpirationIntent = 4 // 续订时产品不可购买
+	ExpirationIntentOther                                ExpirationIntent = 5 // 其他原因
+)
+
+var expirationIntentNames = map[ExpirationIntent]string{
+	ExpirationIntentCustomerCancelled:                    "CUSTOMER_CANCELLED",
+	ExpirationIntentBillingError:                         "BILLING_ERROR",
+	ExpirationIntentCustomerDidNotConsentToPriceIncrease: "CUSTOMER_DID_NOT_CONSENT_TO_PRICE_INCREASE",
+	ExpirationIntentProductNotAvailable:                  "PRODUCT_NOT_AVAILABLE",
+	ExpirationIntentOther:                                "OTHER",
+}
+
+func (e ExpirationIntent) String() string {
+	return enumString(expirationIntentNames, e, "ExpirationIntent")
+}
+
+// IsValid 是否为已知的过期原因
+func (e ExpirationIntent) IsValid() bool {
+	_, ok := expirationIntentNames[e]
+	return ok
+}
+
+// PriceIncreaseStatus 自动续订订阅的涨价状态
+type PriceIncreaseStatus int32
+
+const (
+	PriceIncreaseStatusCustomerHasNotResponded                             PriceIncreaseStatus = 0 // 客户尚未回应需要征得同意的涨价
+	PriceIncreaseStatusCustomerConsentedOrWasNotifiedWithoutNeedingConsent PriceIncreaseStatus = 1 // 客户已同意涨价，或已收到无需同意的涨价通知
+)
+
+var priceIncreaseStatusNames = map[PriceIncreaseStatus]string{
+	PriceIncreaseStatusCustomerHasNotResponded:                             "CUSTOMER_HAS_NOT_RESPONDED",
+	PriceIncreaseStatusCustomerConsentedOrWasNotifiedWithoutNeedingConsent: "CUSTOMER_CONSENTED_OR_WAS_NOTIFIED_WITHOUT_NEEDING_CONSENT",
+}
+
+func (s PriceIncreaseStatus) String() string {
+	return enumString(priceIncreaseStatusNames, s, "PriceIncreaseStatus")
+}
+
+// IsValid 是否为已知的涨价状态
+func (s PriceIncreaseStatus) IsValid() bool {
+	_, ok := priceIncreaseStatusNames[s]
+	return ok
+}
+
+// OfferType 订阅优惠的类型
+type OfferType int32
+
+const (
+	OfferTypeIntroductory          OfferType = 1 // 推介促销优惠
+	OfferTypePromotional           OfferType = 2 // 促销优惠
+	OfferTypeSubscriptionOfferCode OfferType = 3 // 订阅优惠代码
+	OfferTypeWinBack               OfferType = 4 // 赢回优惠
+)
+
+var offerTypeNames = map[OfferType]string{
+	OfferTypeIntroductory:          "INTRODUCTORY",
+	OfferTypePromotional:           "PROMOTIONAL",
+	OfferTypeSubscriptionOfferCode: "SUBSCRIPTION_OFFER_CODE",
+	OfferTypeWinBack:               "WIN_BACK",
+}
+
+func (t OfferType) String() string {
+	return enumString(offerTypeNames, t, "OfferType")
+}
+
+// IsValid 是否为已知的优惠类型
+func (t OfferType) IsValid() bool {
+	_, ok := offerTypeNames[t]
+	return ok
+}
+
+// OfferDiscountType 折扣优惠的付款方式
+type OfferDiscountType string
+
+const (
+	OfferDiscountTypeFreeTrial  OfferDiscountType = "FREE_TRIAL"    // 免费试用
+	OfferDiscountTypePayAsYouGo OfferDiscountType = "PAY_AS_YOU_GO" // 随用随付
+	OfferDiscountTypePayUpFront OfferDiscountType = "PAY_UP_FRONT"  // 预先支付
+	OfferDiscountTypeOneTime    OfferDiscountType = "ONE_TIME"      // 一次性付款
+)
+
+func (t OfferDiscountType) String() string { return string(t) }
+
+// IsValid 是否为已知的付款方式
+func (t OfferDiscountType) IsValid() bool {
+	switch t {
+	case OfferDiscountTypeFreeTrial, OfferDiscountTypePayAsYouGo, OfferDiscountTypePayUpFront, OfferDiscountTypeOneTime:
+		return true
+	}
+	return false
+}
+
+// ProductType 应用内购买的产品类型
+type ProductType string
+
+const (
+	ProductTypeAutoRenewable ProductType = "Auto-Renewable Subscription" // 自动续订订阅
+	ProductTypeNonConsumable ProductType = "Non-Consumable"              // 非消耗型项目
+	ProductTypeConsumable    ProductType = "Consumable"                  // 消耗型项目
+	ProductTypeNonRenewing   ProductType = "Non-Renewing Subscription"   // 非续订订阅
+)
+
+func (t ProductType) String() string { return string(t) }
+
+// IsValid 是否为已知的产品类型
+func (t ProductType) IsValid() bool {
+	switch t {
+	case ProductTypeAutoRenewable, ProductTypeNonConsumable, ProductTypeConsumable, ProductTypeNonRenewing:
+		return true
+	}
+	return false
+}
+
+// InAppOwnershipType 交易是由客户购买，还是通过家庭共享获得
+type InAppOwnershipType string
+
+const (
+	InAppOwnershipTypeFamilyShared InAppOwnershipType = "FAMILY_SHARED" // 通过家庭共享获得
+	InAppOwnershipTypePurchased    InAppOwnershipType = "PURCHASED"     // 客户自己购买
+)
+
+func (t InAppOwnershipType) String() string { return string(t) }
+
+// IsValid 是否为已知的所有权类型
+func (t InAppOwnershipType) IsValid() bool {
+	return t == InAppOwnershipTypeFamilyShared || t == InAppOwnershipTypePurchased
+}
+
+// RevocationReason 交易退款的原因
+type RevocationReason int32
+
+const (
+	RevocationReasonOther    RevocationReason = 0 // 其他原因
+	RevocationReasonAppIssue RevocationReason = 1 // 应用存在实际或感知到的问题
+)
+
+var revocationReasonNames = map[RevocationReason]string{
+	RevocationReasonOther:    "OTHER",
+	RevocationReasonAppIssue: "APP_ISSUE",
+}
+
+func (r RevocationReason) String() string {
+	return enumString(revocationReasonNames, r, "RevocationReason")
+}
+
+// IsValid 是否为已知的退款原因
+func (r RevocationReason) IsValid() bool {
+	_, ok := revocationReasonNames[r]
+	return ok
+}
+
+// TransactionReason 购买交易的原因
+type TransactionReason string
+
+const (
+	TransactionReasonPurchase TransactionReason = "PURCHASE" // 客户发起的购买
+	TransactionReasonRenewal  TransactionReason = "RENEWAL"  // 系统发起的自动续订
+)
+
+func (r TransactionReason) String() string { return string(r) }
+
+// IsValid 是否为已知的交易原因
+func (r TransactionReason) IsValid() bool {
+	return r == TransactionReasonPurchase || r == TransactionReasonRenewal
+}
+
+// ConsumptionRequestReason 客户申请退款的原因
+type ConsumptionRequestReason string
+
+const (
+	ConsumptionRequestReasonUnintendedPurchase      ConsumptionRequestReason = "UNINTENDED_PURCHASE"       // 非本意的购买
+	ConsumptionRequestReasonFulfillmentIssue        ConsumptionRequestReason = "FULFILLMENT_ISSUE"         // 未收到购买的内容
+	ConsumptionRequestReasonUnsatisfiedWithPurchase ConsumptionRequestReason = "UNSATISFIED_WITH_PURCHASE" // 对购买的内容不满意
+	ConsumptionRequestReasonLegal                   ConsumptionRequestReason = "LEGAL"                     // 法律原因
+	ConsumptionRequestReasonOther                   ConsumptionRequestReason = "OTHER"                     // 其他原因
+)
+
+func (r ConsumptionRequestReason) String() string { return string(r) }
+
+// IsValid 是否为已知的退款申请原因
+func (r ConsumptionRequestReason) IsValid() bool {
+	switch r {
+	case ConsumptionRequestReasonUnintendedPurchase, ConsumptionRequestReasonFulfillmentIssue,
+		ConsumptionRequestReasonUnsatisfiedWithPurchase, ConsumptionRequestReasonLegal, ConsumptionRequestReasonOther:
+		return true
+	}
+	return false
+}
+
+// NotificationTypeV2 App Store 服务器通知 V2 的类型
+type NotificationTypeV2 string
+
+const (
+	NotificationTypeSubscribed             NotificationTypeV2 = "SUBSCRIBED"
+	NotificationTypeDidChangeRenewalPref   NotificationTypeV2 = "DID_CHANGE_RENEWAL_PREF"
+	NotificationTypeDidChangeRenewalStatus NotificationTypeV2 = "DID_CHANGE_RENEWAL_STATUS"
+	NotificationTypeOfferRedeemed          NotificationTypeV2 = "OFFER_REDEEMED"
+	NotificationTypeDidRenew               NotificationTypeV2 = "DID_RENEW"
+	NotificationTypeExpired                NotificationTypeV2 = "EXPIRED"
+	NotificationTypeDidFailToRenew         NotificationTypeV2 = "DID_FAIL_TO_RENEW"
+	NotificationTypeGracePeriodExpired     NotificationTypeV2 = "GRACE_PERIOD_EXPIRED"
+	NotificationTypePriceIncrease          NotificationTypeV2 = "PRICE_INCREASE"
+	NotificationTypeRefund                 NotificationTypeV2 = "REFUND"
+	NotificationTypeRefundDeclined         NotificationTypeV2 = "REFUND_DECLINED"
+	NotificationTypeRefundReversed         NotificationTypeV2 = "REFUND_REVERSED"
+	NotificationTypeConsumptionRequest     NotificationTypeV2 = "CONSUMPTION_REQUEST"
+	NotificationTypeRenewalExtended        NotificationTypeV2 = "RENEWAL_EXTENDED"
+	NotificationTypeRenewalExtension       NotificationTypeV2 = "RENEWAL_EXTENSION"
+	NotificationTypeRevoke                 NotificationTypeV2 = "REVOKE"
+	NotificationTypeTest                   NotificationTypeV2 = "TEST"
+	NotificationTypeExternalPurchaseToken  NotificationTypeV2 = "EXTERNAL_PURCHASE_TOKEN"
+	NotificationTypeOneTimeCharge          NotificationTypeV2 = "ONE_TIME_CHARGE"
+)
+
+var notificationTypes = map[NotificationTypeV2]struct{}{
+	NotificationTypeSubscribed: {}, NotificationTypeDidChangeRenewalPref: {}, NotificationTypeDidChangeRenewalStatus: {},
+	NotificationTypeOfferRedeemed: {}, NotificationTypeDidRenew: {}, NotificationTypeExpired: {},
+	NotificationTypeDidFailToRenew: {}, NotificationTypeGracePeriodExpired: {}, NotificationTypePriceIncrease: {},
+	NotificationTypeRefund: {}, NotificationTypeRefundDeclined: {}, NotificationTypeRefundReversed: {},
+	NotificationTypeConsumptionRequest: {}, NotificationTypeRenewalExtended: {}, NotificationTypeRenewalExtension: {},
+	NotificationTypeRevoke: {}, NotificationTypeTest: {}, NotificationTypeExternalPurchaseToken: {},
+	NotificationTypeOneTimeCharge: {},
+}
+
+func (t NotificationTypeV2) String() string { return string(t) }
+
+// IsValid 是否为已知的通知类型
+func (t NotificationTypeV2) IsValid() bool {
+	_, ok := notificationTypes[t]
+	return ok
+}
+
+// Subtype App Store 服务器通知 V2 的子类型
+type Subtype string
+
+const (
+	SubtypeInitialBuy        Subtype = "INITIAL_BUY"
+	SubtypeResubscribe       Subtype = "RESUBSCRIBE"
+	SubtypeDowngrade         Subtype = "DOWNGRADE"
+	SubtypeUpgrade           Subtype = "UPGRADE"
+	SubtypeAutoRenewEnabled  Subtype = "AUTO_RENEW_ENABLED"
+	SubtypeAutoRenewDisabled Subtype = "AUTO_RENEW_DISABLED"
+	SubtypeVoluntary         Subtype = "VOLUNTARY"
+	SubtypeBillingRetry      Subtype = "BILLING_RETRY"
+	SubtypePriceIncrease     Subtype = "PRICE_INCREASE"
+	SubtypeGracePeriod       Subtype = "GRACE_PERIOD"
+	SubtypePending           Subtype = "PENDING"
+	SubtypeAccepted          Subtype = "ACCEPTED"
+	SubtypeBillingRecovery   Subtype = "BILLING_RECOVERY"
+	SubtypeProductNotForSale Subtype = "PRODUCT_NOT_FOR_SALE"
+	SubtypeSummary           Subtype = "SUMMARY"
+	SubtypeFailure           Subtype = "FAILURE"
+	SubtypeUnreported        Subtype = "UNREPORTED"
+)
+
+var subtypes = map[Subtype]struct{}{
+	SubtypeInitialBuy: {}, SubtypeResubscribe: {}, SubtypeDowngrade: {}, SubtypeUpgrade: {},
+	SubtypeAutoRenewEnabled: {}, SubtypeAutoRenewDisabled: {}, SubtypeVoluntary: {}, SubtypeBillingRetry: {},
+	SubtypePriceIncrease: {}, SubtypeGracePeriod: {}, SubtypePending: {}, SubtypeAccepted: {},
+	SubtypeBillingRecovery: {}, SubtypeProductNotForSale: {}, SubtypeSummary: {}, SubtypeFailure: {},
+	SubtypeUnreported: {},
+}
+
+func (s Subtype) String() string { return string(s) }
+
+// IsValid 是否为已知的通知子类型
+func (s Subtype) IsValid() bool {
+	_, ok := subtypes[s]
+	return ok
+}
+
+// OrderLookupStatus 订单号查询的结果
+type OrderLookupStatus int32
+
+const (
+	OrderLookupStatusValid   OrderLookupStatus = 0 // 订单号有效，且包含应用内购买
+	OrderLookupStatusInvalid OrderLookupStatus = 1 // 订单号无效
+)
+
+var orderLookupStatusNames = map[OrderLookupStatus]string{
+	OrderLookupStatusValid:   "VALID",
+	OrderLookupStatusInvalid: "INVALID",
+}
+
+func (s OrderLookupStatus) String() string {
+	return enumString(orderLookupStatusNames, s, "OrderLookupStatus")
+}
+
+// IsValid 是否为已知的查询结果
+func (s OrderLookupStatus) IsValid() bool {
+	_, ok := orderLookupStatusNames[s]
+	return ok
+}
+
+// ExtendReasonCode 延长订阅续订日期的原因
+type ExtendReasonCode int32
+
+const (
+	ExtendReasonCodeUndeclared           ExtendReasonCode = 0 // 未声明原因
+	ExtendReasonCodeCustomerSatisfaction ExtendReasonCode = 1 // 提升客户满意度
+	ExtendReasonCodeOther                ExtendReasonCode = 2 // 其他原因
+	ExtendReasonCodeServiceIssueOrOutage ExtendReasonCode = 3 // 服务问题或中断
+)
+
+var extendReasonCodeNames = map[ExtendReasonCode]string{
+	ExtendReasonCodeUndeclared:           "UNDECLARED",
+	ExtendReasonCodeCustomerSatisfaction: "CUSTOMER_SATISFACTION",
+	ExtendReasonCodeOther:                "OTHER",
+	ExtendReasonCodeServiceIssueOrOutage: "SERVICE_ISSUE_OR_OUTAGE",
+}
+
+func (c ExtendReasonCode) String() string {
+	return enumString(extendReasonCodeNames, c, "ExtendReasonCode")
+}
+
+// IsValid 是否为已知的延期原因
+func (c ExtendReasonCode) IsValid() bool {
+	_, ok := extendReasonCodeNames[c]
+	return ok
+}
+
+// SendAttemptResult App Store 发送通知到你的服务器的结果
+type SendAttemptResult string
+
+const (
+	SendAttemptResultSuccess                      SendAttemptResult = "SUCCESS"
+	SendAttemptResultTimedOut                     SendAttemptResult = "TIMED_OUT"
+	SendAttemptResultTLSIssue                     SendAttemptResult = "TLS_ISSUE"
+	SendAttemptResultCircularRedirect             SendAttemptResult = "CIRCULAR_REDIRECT"
+	SendAttemptResultNoResponse                   SendAttemptResult = "NO_RESPONSE"
+	SendAttemptResultSocketIssue                  SendAttemptResult = "SOCKET_ISSUE"
+	SendAttemptResultUnsupportedCharset           SendAttemptResult = "UNSUPPORTED_CHARSET"
+	SendAttemptResultInvalidResponse              SendAttemptResult = "INVALID_RESPONSE"
+	SendAttemptResultPrematureClose               SendAttemptResult = "PREMATURE_CLOSE"
+	SendAttemptResultUnsuccessfulHTTPResponseCode SendAttemptResult = "UNSUCCESSFUL_HTTP_RESPONSE_CODE"
+	SendAttemptResultOther                        SendAttemptResult = "OTHER"
+)
+
+func (r SendAttemptResult) String() string { return string(r) }
+
+// IsValid 是否为已知的发送结果
+func (r SendAttemptResult) IsValid() bool {
+	switch r {
+	case SendAttemptResultSuccess, SendAttemptResultTimedOut, SendAttemptResultTLSIssue, SendAttemptResultCircularRedirect,
+		SendAttemptResultNoResponse, SendAttemptResultSocketIssue, SendAttemptResultUnsupportedCharset,
+		SendAttemptResultInvalidResponse, SendAttemptResultPrematureClose, SendAttemptResultUnsuccessfulHTTPResponseCode,
+		SendAttemptResultOther:
+		return true
+	}
+	return false
+}
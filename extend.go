@@ -0,0 +1,139 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zeromicro/go-zero/core/logx"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ExtendRenewalDateRequest 延长单个订阅续订日期的请求
+type ExtendRenewalDateRequest struct {
+	ExtendByDays      int32            `json:"extendByDays"`      // 延长的天数，1 到 90 天。
+	ExtendReasonCode  ExtendReasonCode `json:"extendReasonCode"`  // 延长续订日期的原因。
+	RequestIdentifier string           `json:"requestIdentifier"` // 请求的唯一标识符，为空时由 Client 生成。
+}
+
+// Validate 检查请求参数
+func (r *ExtendRenewalDateRequest) Validate() error {
+	if r.ExtendByDays < 1 || r.ExtendByDays > 90 {
+		return fmt.Errorf("extendByDays must be between 1 and 90, got %d", r.ExtendByDays)
+	}
+	if !r.ExtendReasonCode.IsValid() {
+		return fmt.Errorf("invalid extendReasonCode %s", r.ExtendReasonCode)
+	}
+	if len(r.RequestIdentifier) > 128 {
+		return errors.New("requestIdentifier must not exceed 128 characters")
+	}
+	return nil
+}
+
+// ExtendRenewalDateResponse 延长单个订阅续订日期的结果
+type ExtendRenewalDateResponse struct {
+	OriginalTransactionId string    `json:"originalTransactionId"` // 订阅的原始交易标识符。
+	WebOrderLineItemId    string    `json:"webOrderLineItemId"`    // 订阅购买事件的唯一标识符。
+	Success               bool      `json:"success"`               // 是否成功延长了续订日期。
+	EffectiveDate         Timestamp `json:"effectiveDate"`         // 延长后新的续订日期。
+}
+
+// ExtendRequestStore 保存尚未得到确定结果的延期请求的 requestIdentifier。
+// 网络失败后重试同一个延期请求时会复用保存的 requestIdentifier，App Store 据此识别重复请求，
+// 保证订阅不会被重复延长；需要跨进程重试时可以用数据库或 Redis 实现此接口
+type ExtendRequestStore interface {
+	// LoadOrStore 原子地读取 key 保存的标识符，没有时保存并返回 candidate，
+	// 并发发送同一个延期请求时必须得到同一个标识符
+	LoadOrStore(ctx context.Context, key, candidate string) (actual string, err error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DefaultExtendRequestRetention 未指定时内存中保存 requestIdentifier 的时间，超过后同一个延期请求会使用新的标识符
+const DefaultExtendRequestRetention = 24 * time.Hour
+
+// MemoryExtendRequestStore 保存在内存中的 ExtendRequestStore，标识符保存 retention 后过期，进程重启后失效
+type MemoryExtendRequestStore struct {
+	mu        sync.Mutex
+	records   map[string]extendRequestRecord
+	retention time.Duration
+}
+
+type extendRequestRecord struct {
+	requestIdentifier string
+	expiresAt         time.Time
+}
+
+// NewMemoryExtendRequestStore 创建内存中的 ExtendRequestStore，retention 小于等于 0 时为 DefaultExtendRequestRetention
+func NewMemoryExtendRequestStore(retention time.Duration) *MemoryExtendRequestStore {
+	if retention <= 0 {
+		retention = DefaultExtendRequestRetention
+	}
+	return &MemoryExtendRequestStore{records: map[string]extendRequestRecord{}, retention: retention}
+}
+
+// LoadOrStore 返回未过期的标识符，没有时保存 candidate，同时清理已过期的标识符
+func (s *MemoryExtendRequestStore) LoadOrStore(_ context.Context, key, candidate string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if record, ok := s.records[key]; ok && now.Before(record.expiresAt) {
+		return record.requestIdentifier, nil
+	}
+	for k, record := range s.records {
+		if !now.Before(record.expiresAt) {
+			delete(s.records, k)
+		}
+	}
+	s.records[key] = extendRequestRecord{requestIdentifier: candidate, expiresAt: now.Add(s.retention)}
+	return candidate, nil
+}
+
+func (s *MemoryExtendRequestStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// ExtendSubscriptionRenewalDate 延长单个订阅的续订日期（Extend a Subscription Renewal Date）：
+// originalTransactionId 订阅的原始交易ID
+// request.RequestIdentifier 为空时，复用 Client.ExtendRequests 中同一订阅、同一延期参数尚未完成的标识符，
+// 没有则生成新的 UUID 并保存；生成的标识符只用于本次发送，不会回写到 request，同一个 request 可以用于多个订阅；
+// 得到确定结果（成功或不可重试的错误）后删除保存的标识符，网络错误、限流或 Apple 服务端错误时保留，以便重试
+func (c *Client) ExtendSubscriptionRenewalDate(ctx context.Context, originalTransactionId string, request *ExtendRenewalDateRequest) (*ExtendRenewalDateResponse, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	body := *request
+	store := c.ExtendRequests
+	key := fmt.Sprintf("%s:%d:%d", originalTransactionId, request.ExtendByDays, request.ExtendReasonCode)
+	if body.RequestIdentifier == "" {
+		requestIdentifier, err := newUUID()
+		if err != nil {
+			return nil, err
+		}
+		if store != nil {
+			if requestIdentifier, err = store.LoadOrStore(ctx, key, requestIdentifier); err != nil {
+				return nil, err
+			}
+		}
+		body.RequestIdentifier = requestIdentifier
+	}
+
+	response := &ExtendRenewalDateResponse{}
+	err := c.sendJSON(ctx, http.MethodPut, c.endpoint("/inApps/v1/subscriptions/extend/%s", url.PathEscape(originalTransactionId)), &body, response)
+
+	var apiErr *APIError
+	if store != nil && (err == nil || errors.As(err, &apiErr) && !apiErr.Retryable()) {
+		if deleteErr := store.Delete(ctx, key); deleteErr != nil {
+			logx.Errorf("failed to delete extend request identifier %s: %v", key, deleteErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,123 @@
+package apple
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryExtendRequestStoreLoadOrStore(t *testing.T) {
+	store := NewMemoryExtendRequestStore(0)
+	const n = 32
+	actual := make([]string, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if actual[i], err = store.LoadOrStore(context.Background(), "1000000000000001:30:1", fmt.Sprintf("candidate-%d", i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range actual {
+		if actual[i] != actual[0] {
+			t.Fatalf("LoadOrStore returned %q and %q for the same key", actual[0], actual[i])
+		}
+	}
+
+	if err := store.Delete(context.Background(), "1000000000000001:30:1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.LoadOrStore(context.Background(), "1000000000000001:30:1", "next"); got != "next" {
+		t.Fatalf("LoadOrStore after Delete = %q, want the new candidate", got)
+	}
+
+	expiring := NewMemoryExtendRequestStore(time.Millisecond)
+	expiring.LoadOrStore(context.Background(), "key", "first")
+	time.Sleep(2 * time.Millisecond)
+	if got, _ := expiring.LoadOrStore(context.Background(), "key", "second"); got != "second" {
+		t.Fatalf("LoadOrStore after retention = %q, want the new candidate", got)
+	}
+}
+
+func TestExtendSubscriptionRenewalDateConcurrent(t *testing.T) {
+	const n = 8
+	var (
+		mu          sync.Mutex
+		identifiers = map[string]int{}
+		arrived     sync.WaitGroup
+	)
+	arrived.Add(n)
+	client := newTestClient(t, newTestPKI(t, ""), func(w http.ResponseWriter, r *http.Request) {
+		var body ExtendRenewalDateRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		identifiers[body.RequestIdentifier]++
+		mu.Unlock()
+		// 所有请求都到达后再返回，保证它们是并发发出的
+		arrived.Done()
+		arrived.Wait()
+		w.Write([]byte(`{"originalTransactionId":"1000000000000001","success":true}`))
+	})
+
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ExtendSubscriptionRenewalDate(context.Background(), "1000000000000001",
+				&ExtendRenewalDateRequest{ExtendByDays: 30, ExtendReasonCode: ExtendReasonCodeCustomerSatisfaction})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(identifiers) != 1 {
+		t.Fatalf("App Store received %d request identifiers, want 1: %v", len(identifiers), identifiers)
+	}
+}
+
+func TestExtendSubscriptionRenewalDateRetry(t *testing.T) {
+	var identifiers []string
+	status := http.StatusServiceUnavailable
+	client := newTestClient(t, newTestPKI(t, ""), func(w http.ResponseWriter, r *http.Request) {
+		var body ExtendRenewalDateRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		identifiers = append(identifiers, body.RequestIdentifier)
+		w.WriteHeader(status)
+		w.Write([]byte(`{}`))
+	})
+	request := &ExtendRenewalDateRequest{ExtendByDays: 30, ExtendReasonCode: ExtendReasonCodeServiceIssueOrOutage}
+
+	if _, err := client.ExtendSubscriptionRenewalDate(context.Background(), "1000000000000001", request); err == nil {
+		t.Fatal("ExtendSubscriptionRenewalDate() succeeded on 503")
+	}
+	status = http.StatusOK
+	if _, err := client.ExtendSubscriptionRenewalDate(context.Background(), "1000000000000001", request); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ExtendSubscriptionRenewalDate(context.Background(), "1000000000000001", request); err != nil {
+		t.Fatal(err)
+	}
+
+	// 503 后重试复用标识符，成功后的新请求使用新的标识符
+	if identifiers[0] != identifiers[1] || identifiers[1] == identifiers[2] {
+		t.Fatalf("request identifiers = %v", identifiers)
+	}
+	if request.RequestIdentifier != "" {
+		t.Fatal("generated request identifier was written back to the request")
+	}
+}
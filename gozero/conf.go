@@ -0,0 +1,65 @@
+package gozero
+
+import (
+	"errors"
+	"fmt"
+	"github.com/WuJieOnce/apple"
+	"os"
+)
+
+// Conf 通过 go-zero 的 conf 加载的配置，例如：
+//
+//	Apple:
+//	  Sandbox: true
+//	  Kid: 2X9R4HXF34
+//	  Iss: 57246542-96fe-1a63-e053-0824d011072a
+//	  Bid: com.example.testbundleid
+//	  PrivateKeyFile: etc/SubscriptionKey_2X9R4HXF34.p8
+type Conf struct {
+	Sandbox          bool   `json:",optional"` // 是否使用沙箱环境
+	Kid              string // 来自 App Store Connect 的私钥 ID
+	Iss              string // App Store Connect 中“密钥”页面中的颁发者 ID
+	Bid              string // 应用的 Bundle ID
+	PrivateKey       string `json:",optional"`                                   // .p8 私钥的内容，与 PrivateKeyFile 二选一
+	PrivateKeyFile   string `json:",optional"`                                   // .p8 私钥文件的路径
+	AppAppleId       int64  `json:",optional"`                                   // 应用在 App Store 中的唯一标识符，生产环境必填
+	NotificationPath string `json:",default=/apple/notifications"`               // 接收 App Store 服务器通知的路径
+	EntitlementPath  string `json:",default=/apple/entitlements/:transactionId"` // RegisterEntitlementHandler 注册的查询客户权益的路径，必须包含 :transactionId
+}
+
+// Config 转换为 apple.Config，设置了 PrivateKeyFile 时从文件读取私钥
+func (c Conf) Config() (*apple.Config, error) {
+	privateKey := c.PrivateKey
+	if c.PrivateKeyFile != "" {
+		data, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key file: %v", err)
+		}
+		privateKey = string(data)
+	}
+	if privateKey == "" {
+		return nil, errors.New("either PrivateKey or PrivateKeyFile is required")
+	}
+	return &apple.Config{
+		Sandbox:    c.Sandbox,
+		Kid:        c.Kid,
+		PrivateKey: privateKey,
+		Iss:        c.Iss,
+		Bid:        c.Bid,
+	}, nil
+}
+
+// NewClient 根据配置创建设置了 Verifier 的 apple.Client，Verifier 信任内置的 Apple 根证书
+func NewClient(c Conf) (*apple.Client, error) {
+	config, err := c.Config()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := apple.NewSignedDataVerifier(config, c.AppAppleId, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := apple.NewClient(config)
+	client.Verifier = verifier
+	return client, nil
+}
@@ -0,0 +1,77 @@
+package gozero
+
+import (
+	"errors"
+	"github.com/WuJieOnce/apple"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"net/http"
+)
+
+// EntitlementResponse 查询客户权益的响应
+type EntitlementResponse struct {
+	Entitlements []*apple.Entitlement `json:"entitlements"`
+}
+
+// RegisterHandlers 在 go-zero 的 rest.Server 上注册 POST Conf.NotificationPath，接收 App Store 服务器通知并交给 handler 处理。
+// handler.Verifier 为空时使用 client.Verifier，两者都为空时返回错误，不注册路由
+func RegisterHandlers(server *rest.Server, c Conf, client *apple.Client, handler *apple.NotificationHandler, opts ...rest.RouteOption) error {
+	if handler.Verifier == nil && client != nil {
+		handler.Verifier = client.Verifier
+	}
+	if handler.Verifier == nil {
+		return errors.New("notification handler has no SignedDataVerifier")
+	}
+	server.AddRoutes([]rest.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    c.NotificationPath,
+			Handler: handler.ServeHTTP,
+		},
+	}, opts...)
+	return nil
+}
+
+// RegisterEntitlementHandler 在 go-zero 的 rest.Server 上注册 GET Conf.EntitlementPath，查询客户的权益，返回 EntitlementResponse。
+// 任何交易ID都可以查询到对应客户的权益，auth 必须校验调用方的身份（例如只允许查询自己的交易），为空时返回错误，不注册路由
+func RegisterEntitlementHandler(server *rest.Server, c Conf, client *apple.Client, auth rest.Middleware, opts ...rest.RouteOption) error {
+	if auth == nil {
+		return errors.New("entitlement route requires an auth middleware")
+	}
+	server.AddRoutes([]rest.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    c.EntitlementPath,
+			Handler: auth(EntitlementHandler(client)),
+		},
+	}, opts...)
+	return nil
+}
+
+// EntitlementHandler 查询客户权益，路径参数 transactionId 为客户任意一笔交易的交易ID
+func EntitlementHandler(client *apple.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			TransactionId string `path:"transactionId"`
+		}
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+			return
+		}
+
+		entitlements, err := client.Entitlements(r.Context(), req.TransactionId)
+		if err != nil {
+			var apiErr *apple.APIError
+			if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusNotFound) {
+				httpx.WriteJsonCtx(r.Context(), w, apiErr.StatusCode, apiErr)
+				return
+			}
+			logx.WithContext(r.Context()).Errorf("failed to get entitlements of transaction %s: %v", req.TransactionId, err)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		httpx.OkJsonCtx(r.Context(), w, &EntitlementResponse{Entitlements: entitlements})
+	}
+}
@@ -0,0 +1,91 @@
+package gozero
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WuJieOnce/apple"
+	"github.com/zeromicro/go-zero/rest/pathvar"
+)
+
+// newTestClient 返回请求发往 handler 的沙箱环境 apple.Client
+func newTestClient(t *testing.T, handler http.HandlerFunc) *apple.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	sandboxURL := apple.SandboxURL
+	apple.SandboxURL = server.URL
+	t.Cleanup(func() {
+		apple.SandboxURL = sandboxURL
+		server.Close()
+	})
+
+	config := &apple.Config{Sandbox: true, Bid: "com.example"}
+	verifier, err := apple.NewSignedDataVerifier(config, 0, apple.NewTrustStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := apple.NewClient(config)
+	authorization := "test"
+	client.Authorization = &authorization
+	client.Verifier = verifier
+	return client
+}
+
+func TestRegisterHandlersRequiresVerifier(t *testing.T) {
+	// 校验失败时不会使用 server
+	err := RegisterHandlers(nil, Conf{NotificationPath: "/apple/notifications"}, apple.NewClient(&apple.Config{}), apple.NewNotificationHandler(nil))
+	if err == nil {
+		t.Fatal("RegisterHandlers() accepted a handler without verifier")
+	}
+}
+
+func TestRegisterEntitlementHandlerRequiresAuth(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	})
+	if err := RegisterEntitlementHandler(nil, Conf{EntitlementPath: "/apple/entitlements/:transactionId"}, client, nil); err == nil {
+		t.Fatal("RegisterEntitlementHandler() accepted a nil auth middleware")
+	}
+}
+
+func TestEntitlementHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+	}{
+		{name: "transaction not found", status: http.StatusNotFound, body: `{"errorCode":4040010,"errorMessage":"Transaction id not found."}`, wantStatus: http.StatusNotFound},
+		{name: "invalid transaction id", status: http.StatusBadRequest, body: `{"errorCode":4000006,"errorMessage":"Invalid transaction id."}`, wantStatus: http.StatusBadRequest},
+		{name: "app store unavailable", status: http.StatusInternalServerError, body: `{"errorCode":5000000,"errorMessage":"An unknown error occurred."}`, wantStatus: http.StatusBadGateway},
+		{name: "no subscriptions", status: http.StatusOK, body: `{"environment":"Sandbox","bundleId":"com.example","data":[]}`, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/inApps/v1/subscriptions/1000000000000001" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/apple/entitlements/1000000000000001", nil)
+			r = pathvar.WithVars(r, map[string]string{"transactionId": "1000000000000001"})
+			w := httptest.NewRecorder()
+			EntitlementHandler(client)(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusNotFound {
+				var apiErr apple.APIError
+				if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil || apiErr.ErrorCode != 4040010 {
+					t.Fatalf("body = %s, want the App Store error", w.Body)
+				}
+			}
+		})
+	}
+}
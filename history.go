@@ -0,0 +1,151 @@
+package apple
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// HistoryProductType 查询交易历史时按产品类型过滤的取值，与 ProductType 的取值不同
+type HistoryProductType string
+
+const (
+	HistoryProductTypeAutoRenewable HistoryProductType = "AUTO_RENEWABLE" // 自动续订订阅
+	HistoryProductTypeNonRenewable  HistoryProductType = "NON_RENEWABLE"  // 非续订订阅
+	HistoryProductTypeConsumable    HistoryProductType = "CONSUMABLE"     // 消耗型项目
+	HistoryProductTypeNonConsumable HistoryProductType = "NON_CONSUMABLE" // 非消耗型项目
+)
+
+// SortOrder 交易历史的排序方式
+type SortOrder string
+
+const (
+	SortOrderAscending  SortOrder = "ASCENDING"  // 按修改时间升序
+	SortOrderDescending SortOrder = "DESCENDING" // 按修改时间降序
+)
+
+// TransactionHistoryRequest 查询交易历史的过滤条件，零值表示不过滤
+type TransactionHistoryRequest struct {
+	StartDate                    Timestamp            // 只返回此时间（含）之后的交易
+	EndDate                      Timestamp            // 只返回此时间之前的交易
+	ProductIds                   []string             // 只返回这些产品的交易
+	ProductTypes                 []HistoryProductType // 只返回这些产品类型的交易
+	SubscriptionGroupIdentifiers []string             // 只返回这些订阅组的交易
+	InAppOwnershipType           InAppOwnershipType   // 只返回购买或家庭共享获得的交易
+	Revoked                      *bool                // true 只返回已退款或撤销的交易，false 只返回未撤销的交易
+	Sort                         SortOrder            // 排序方式，默认升序
+}
+
+func (r *TransactionHistoryRequest) query(revision string) url.Values {
+	query := url.Values{}
+	if revision != "" {
+		query.Set("revision", revision)
+	}
+	if r == nil {
+		return query
+	}
+	if r.StartDate != 0 {
+		query.Set("startDate", strconv.FormatInt(int64(r.StartDate), 10))
+	}
+	if r.EndDate != 0 {
+		query.Set("endDate", strconv.FormatInt(int64(r.EndDate), 10))
+	}
+	for _, productId := range r.ProductIds {
+		query.Add("productId", productId)
+	}
+	for _, productType := range r.ProductTypes {
+		query.Add("productType", string(productType))
+	}
+	for _, group := range r.SubscriptionGroupIdentifiers {
+		query.Add("subscriptionGroupIdentifier", group)
+	}
+	if r.InAppOwnershipType != "" {
+		query.Set("inAppOwnershipType", string(r.InAppOwnershipType))
+	}
+	if r.Revoked != nil {
+		query.Set("revoked", strconv.FormatBool(*r.Revoked))
+	}
+	if r.Sort != "" {
+		query.Set("sort", string(r.Sort))
+	}
+	return query
+}
+
+// HistoryResponse 交易历史的一页数据
+type HistoryResponse struct {
+	Revision           string      `json:"revision"`           // 下一页请求需要带上的 revision。
+	HasMore            bool        `json:"hasMore"`            // 是否还有更多交易。
+	BundleId           string      `json:"bundleId"`           // 应用的 Bundle ID。
+	AppAppleId         int64       `json:"appAppleId"`         // 应用在 App Store 中的唯一标识符。
+	Environment        Environment `json:"environment"`        // 服务器环境，沙箱或生产环境。
+	SignedTransactions []string    `json:"signedTransactions"` // App Store 签名的交易信息，JWS 格式。
+}
+
+// GetTransactionHistory 查询交易历史的一页（Get Transaction History V2）：
+// transactionId 客户任意一笔交易的交易ID
+// revision 为空时查询第一页，之后传入上一页返回的 Revision
+func (c *Client) GetTransactionHistory(ctx context.Context, transactionId string, request *TransactionHistoryRequest, revision string) (*HistoryResponse, error) {
+	endpoint := c.endpoint("/inApps/v2/history/%s", url.PathEscape(transactionId))
+	if query := request.query(revision).Encode(); query != "" {
+		endpoint += "?" + query
+	}
+
+	response := &HistoryResponse{}
+	if err := c.send(ctx, http.MethodGet, endpoint, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// TransactionHistory 遍历客户的全部交易历史，自动请求后续分页，并通过 Client.Verifier 校验解码每一笔交易：
+//
+//	for transaction, err := range client.TransactionHistory(ctx, transactionId, nil) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// 出错时产出 (nil, err) 后结束遍历
+func (c *Client) TransactionHistory(ctx context.Context, transactionId string, request *TransactionHistoryRequest) iter.Seq2[*JWSTransactionDecodedPayload, error] {
+	return c.signedTransactionPages(func(revision string) ([]string, string, bool, error) {
+		page, err := c.GetTransactionHistory(ctx, transactionId, request, revision)
+		if err != nil {
+			return nil, "", false, err
+		}
+		return page.SignedTransactions, page.Revision, page.HasMore, nil
+	})
+}
+
+// signedTransactionPages 按 revision 依次请求每一页，并通过 Client.Verifier 校验解码其中的交易；
+// fetch 返回一页的签名交易、下一页的 revision 以及是否还有更多
+func (c *Client) signedTransactionPages(fetch func(revision string) ([]string, string, bool, error)) iter.Seq2[*JWSTransactionDecodedPayload, error] {
+	return func(yield func(*JWSTransactionDecodedPayload, error) bool) {
+		verifier, err := c.verifier()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		revision := ""
+		for {
+			signedTransactions, next, hasMore, err := fetch(revision)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, signed := range signedTransactions {
+				transaction, err := verifier.VerifyAndDecodeTransaction(signed)
+				if !yield(transaction, err) || err != nil {
+					return
+				}
+			}
+			if !hasMore || next == "" {
+				return
+			}
+			revision = next
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zeromicro/go-zero/core/logx"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MassExtendRenewalDateRequest 为某个产品的全部有效订阅批量延长续订日期的请求
+type MassExtendRenewalDateRequest struct {
+	RequestIdentifier      string           `json:"requestIdentifier"`                // 请求的唯一标识符，为空时由 PlanMassExtension 生成。
+	ExtendByDays           int32            `json:"extendByDays"`                     // 延长的天数，1 到 90 天。
+	ExtendReasonCode       ExtendReasonCode `json:"extendReasonCode"`                 // 延长续订日期的原因。
+	ProductId              string           `json:"productId"`                        // 自动续订订阅的产品标识符。
+	StorefrontCountryCodes []string         `json:"storefrontCountryCodes,omitempty"` // 只延长这些店面（ISO 3166-1 alpha-3 国家代码）的订阅，为空时延长全部店面。
+}
+
+// Validate 检查请求参数
+func (r *MassExtendRenewalDateRequest) Validate() error {
+	if r.ProductId == "" {
+		return errors.New("productId is required")
+	}
+	if r.ExtendByDays < 1 || r.ExtendByDays > 90 {
+		return fmt.Errorf("extendByDays must be between 1 and 90, got %d", r.ExtendByDays)
+	}
+	if !r.ExtendReasonCode.IsValid() {
+		return fmt.Errorf("invalid extendReasonCode %s", r.ExtendReasonCode)
+	}
+	if len(r.RequestIdentifier) > 128 {
+		return errors.New("requestIdentifier must not exceed 128 characters")
+	}
+	for _, code := range r.StorefrontCountryCodes {
+		if !isCountryCode(code) {
+			return fmt.Errorf("invalid storefront country code %q", code)
+		}
+	}
+	return nil
+}
+
+func isCountryCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// MassExtendRenewalDateResponse 批量延期请求已被 App Store 接受
+type MassExtendRenewalDateResponse struct {
+	RequestIdentifier string `json:"requestIdentifier"` // 请求的唯一标识符。
+}
+
+// MassExtendRenewalDateStatusResponse 批量延期请求的处理状态
+type MassExtendRenewalDateStatusResponse struct {
+	RequestIdentifier string    `json:"requestIdentifier"` // 请求的唯一标识符。
+	Complete          bool      `json:"complete"`          // App Store 是否已处理完全部订阅。
+	CompleteDate      Timestamp `json:"completeDate"`      // 处理完成的时间。
+	SucceededCount    int64     `json:"succeededCount"`    // 成功延长续订日期的订阅数量。
+	FailedCount       int64     `json:"failedCount"`       // 未能延长续订日期的订阅数量。
+}
+
+// MassExtensionPlan 批量延期的预演结果。批量延期会影响产品的全部订阅者，
+// 必须先通过 PlanMassExtension 生成预演结果，核对后再携带确认码调用 ExecuteMassExtension。
+// 字段不可导出，只能由 PlanMassExtension 生成，执行的请求与核对时看到的一致
+type MassExtensionPlan struct {
+	request          MassExtendRenewalDateRequest // 将要发送的请求，RequestIdentifier 已确定
+	requestedBy      string                       // 发起人，记录在审计记录中
+	plannedAt        time.Time                    // 生成预演结果的时间
+	confirmationCode string                       // 执行时需要原样提供的确认码，包含随机部分
+}
+
+// Request 返回将要发送的请求
+func (p *MassExtensionPlan) Request() MassExtendRenewalDateRequest {
+	request := p.request
+	request.StorefrontCountryCodes = append([]string(nil), p.request.StorefrontCountryCodes...)
+	return request
+}
+
+// RequestedBy 返回发起人
+func (p *MassExtensionPlan) RequestedBy() string {
+	return p.requestedBy
+}
+
+// PlannedAt 返回生成预演结果的时间
+func (p *MassExtensionPlan) PlannedAt() time.Time {
+	return p.plannedAt
+}
+
+// ConfirmationCode 返回执行时需要原样提供的确认码
+func (p *MassExtensionPlan) ConfirmationCode() string {
+	return p.confirmationCode
+}
+
+// String 返回供发起人核对的预演说明
+func (p *MassExtensionPlan) String() string {
+	storefronts := "ALL storefronts"
+	if len(p.request.StorefrontCountryCodes) > 0 {
+		storefronts = "storefronts " + strings.Join(p.request.StorefrontCountryCodes, ",")
+	}
+	return fmt.Sprintf("extend every active subscription of %s in %s by %d days (reason %s, request %s, requested by %s); confirm with %q",
+		p.request.ProductId, storefronts, p.request.ExtendByDays, p.request.ExtendReasonCode,
+		p.request.RequestIdentifier, p.requestedBy, p.confirmationCode)
+}
+
+// MassExtensionAudit 批量延期的审计记录，发送请求前记录一次（Response 和 Err 都为空），
+// 得到结果后再记录一次（成功时 Response 不为空，失败时 Err 不为空）
+type MassExtensionAudit struct {
+	Plan        *MassExtensionPlan             // 执行的预演结果
+	ConfirmedAt time.Time                      // 确认执行的时间
+	CompletedAt time.Time                      // 得到结果的时间，发送请求前为零值
+	Response    *MassExtendRenewalDateResponse // App Store 接受请求的结果，成功时不为空
+	Err         error                          // 请求失败的原因，成功时为 nil
+}
+
+// MassExtensionAuditor 记录批量延期的审计记录，发送请求前的记录失败时不会发送请求
+type MassExtensionAuditor interface {
+	RecordMassExtension(ctx context.Context, audit *MassExtensionAudit) error
+}
+
+// logMassExtensionAuditor 未设置 Client.MassExtensionAuditor 时使用，将审计记录写入日志
+type logMassExtensionAuditor struct{}
+
+func (logMassExtensionAuditor) RecordMassExtension(_ context.Context, audit *MassExtensionAudit) error {
+	switch {
+	case audit.Err != nil:
+		logx.Errorf("mass extension failed: %s: %v", audit.Plan, audit.Err)
+	case audit.Response != nil:
+		logx.Infof("mass extension accepted at %s: %s", audit.CompletedAt.Format(time.RFC3339), audit.Plan)
+	default:
+		logx.Infof("mass extension confirmed at %s: %s", audit.ConfirmedAt.Format(time.RFC3339), audit.Plan)
+	}
+	return nil
+}
+
+// ErrMassExtensionNotConfirmed 执行批量延期时提供的确认码与预演结果不一致，或预演结果不是由 PlanMassExtension 生成的
+var ErrMassExtensionNotConfirmed = errors.New("mass extension is not confirmed")
+
+// PlanMassExtension 预演批量延期：检查参数、确定 requestIdentifier 并生成随机的确认码，不会发送任何请求：
+// requestedBy 发起人（例如运营人员的账号），必填
+func (c *Client) PlanMassExtension(request *MassExtendRenewalDateRequest, requestedBy string) (*MassExtensionPlan, error) {
+	if requestedBy == "" {
+		return nil, errors.New("requestedBy is required")
+	}
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan := &MassExtensionPlan{
+		request:     *request,
+		requestedBy: requestedBy,
+		plannedAt:   time.Now(),
+	}
+	plan.request.StorefrontCountryCodes = append([]string(nil), request.StorefrontCountryCodes...)
+	if plan.request.RequestIdentifier == "" {
+		requestIdentifier, err := newUUID()
+		if err != nil {
+			return nil, err
+		}
+		plan.request.RequestIdentifier = requestIdentifier
+	}
+	nonce, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	plan.confirmationCode = fmt.Sprintf("extend %s by %d days %s", plan.request.ProductId, plan.request.ExtendByDays, nonce[:8])
+	return plan, nil
+}
+
+// ExecuteMassExtension 执行预演过的批量延期（Extend Subscription Renewal Dates for All Active Subscribers）：
+// confirmation 必须与 plan.ConfirmationCode() 一致；发送请求前和得到结果后都会写入审计记录。
+// 同一个 plan 重复执行时使用相同的 requestIdentifier，App Store 不会重复延期
+func (c *Client) ExecuteMassExtension(ctx context.Context, plan *MassExtensionPlan, confirmation string) (*MassExtendRenewalDateResponse, error) {
+	if plan == nil || plan.confirmationCode == "" || confirmation != plan.confirmationCode {
+		return nil, ErrMassExtensionNotConfirmed
+	}
+	if plan.requestedBy == "" {
+		return nil, errors.New("requestedBy is required")
+	}
+	if err := plan.request.Validate(); err != nil {
+		return nil, err
+	}
+
+	auditor := c.MassExtensionAuditor
+	if auditor == nil {
+		auditor = logMassExtensionAuditor{}
+	}
+	confirmed := &MassExtensionAudit{Plan: plan, ConfirmedAt: time.Now()}
+	if err := auditor.RecordMassExtension(ctx, confirmed); err != nil {
+		return nil, fmt.Errorf("failed to record mass extension audit: %w", err)
+	}
+
+	response := &MassExtendRenewalDateResponse{}
+	err := c.sendJSON(ctx, http.MethodPost, c.endpoint("/inApps/v1/subscriptions/extend/mass"), &plan.request, response)
+
+	completed := &MassExtensionAudit{Plan: plan, ConfirmedAt: confirmed.ConfirmedAt, CompletedAt: time.Now(), Err: err}
+	if err == nil {
+		completed.Response = response
+	}
+	if auditErr := auditor.RecordMassExtension(ctx, completed); auditErr != nil {
+		logx.Errorf("failed to record mass extension audit: %v", auditErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetMassExtensionStatus 查询批量延期请求的处理状态（Get Status of Subscription Renewal Date Extensions）
+func (c *Client) GetMassExtensionStatus(ctx context.Context, productId, requestIdentifier string) (*MassExtendRenewalDateStatusResponse, error) {
+	endpoint := c.endpoint("/inApps/v1/subscriptions/extend/mass/%s/%s", url.PathEscape(productId), url.PathEscape(requestIdentifier))
+	response := &MassExtendRenewalDateStatusResponse{}
+	if err := c.send(ctx, http.MethodGet, endpoint, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// WaitMassExtension 每隔 interval（小于等于 0 时为 1 分钟）查询一次批量延期的状态，直到处理完成或 ctx 结束，
+// 返回成功和失败的订阅数量；网络错误、限流和 Apple 服务端错误时继续等待，其他 App Store 错误直接返回
+func (c *Client) WaitMassExtension(ctx context.Context, productId, requestIdentifier string, interval time.Duration) (*MassExtendRenewalDateStatusResponse, error) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := c.GetMassExtensionStatus(ctx, productId, requestIdentifier)
+		var apiErr *APIError
+		switch {
+		case err == nil && status.Complete:
+			return status, nil
+		case errors.As(err, &apiErr) && !apiErr.Retryable():
+			return nil, err
+		case err != nil && ctx.Err() == nil:
+			logx.Errorf("failed to get mass extension status %s: %v", requestIdentifier, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
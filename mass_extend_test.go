@@ -0,0 +1,87 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWaitMassExtension(t *testing.T) {
+	// 依次返回的结果：0 表示断开连接（网络错误），其他为状态码，用完后返回已完成
+	tests := []struct {
+		name         string
+		responses    []int
+		timeout      time.Duration
+		wantRequests int
+		wantErr      func(err error) bool
+	}{
+		{
+			name:         "network and server errors are retried",
+			responses:    []int{0, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			wantRequests: 5,
+			wantErr:      func(err error) bool { return err == nil },
+		},
+		{
+			name:         "client errors are returned",
+			responses:    []int{0, http.StatusNotFound},
+			wantRequests: 2,
+			wantErr: func(err error) bool {
+				var apiErr *APIError
+				return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+			},
+		},
+		{
+			name:      "network errors until ctx ends",
+			responses: make([]int, 1000),
+			timeout:   50 * time.Millisecond,
+			wantErr:   func(err error) bool { return errors.Is(err, context.DeadlineExceeded) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			client := newTestClient(t, newTestPKI(t, ""), func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.URL.Path != "/inApps/v1/subscriptions/extend/mass/com.example.monthly/request-1" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				switch {
+				case requests > len(tt.responses):
+					w.Write([]byte(`{"requestIdentifier":"request-1","complete":true,"succeededCount":30,"failedCount":2}`))
+				case tt.responses[requests-1] == 0:
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					conn.Close()
+				default:
+					status := tt.responses[requests-1]
+					w.WriteHeader(status)
+					if status == http.StatusOK {
+						w.Write([]byte(`{"requestIdentifier":"request-1","complete":false}`))
+					}
+				}
+			})
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			status, err := client.WaitMassExtension(ctx, "com.example.monthly", "request-1", time.Millisecond)
+			if !tt.wantErr(err) {
+				t.Fatalf("WaitMassExtension() error = %v", err)
+			}
+			if err == nil && (!status.Complete || status.SucceededCount != 30 || status.FailedCount != 2) {
+				t.Fatalf("status = %+v", status)
+			}
+			if tt.wantRequests > 0 && requests != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
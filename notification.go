@@ -0,0 +1,53 @@
+package apple
+
+// ResponseBodyV2 App Store 服务器通知 V2 的请求体
+type ResponseBodyV2 struct {
+	SignedPayload string `json:"signedPayload"` // App Store 签名的通知，JWS 格式。
+}
+
+// ResponseBodyV2DecodedPayload App Store 服务器通知 V2 的 signedPayload 解码后的内容
+type ResponseBodyV2DecodedPayload struct {
+	NotificationType      NotificationTypeV2     `json:"notificationType"`      // 通知的类型。
+	Subtype               Subtype                `json:"subtype"`               // 通知的子类型。
+	NotificationUUID      string                 `json:"notificationUUID"`      // 通知的唯一标识符。
+	Version               string                 `json:"version"`               // App Store 服务器通知的版本号。
+	SignedDate            Timestamp              `json:"signedDate"`            // App Store 签署 JSON Web 签名 (JWS) 数据的 UNIX 时间（以毫秒为单位）。
+	Data                  *NotificationData      `json:"data"`                  // 包含应用元数据以及签名的续订和交易信息的对象。
+	Summary               *NotificationSummary   `json:"summary"`               // 批量延长订阅续订日期请求完成后的汇总信息，仅出现在 RENEWAL_EXTENSION 的 SUMMARY 通知中。
+	ExternalPurchaseToken *ExternalPurchaseToken `json:"externalPurchaseToken"` // 外部购买令牌，仅出现在 EXTERNAL_PURCHASE_TOKEN 通知中。
+}
+
+// NotificationData 通知中与应用和交易相关的数据
+type NotificationData struct {
+	AppAppleId               int64                    `json:"appAppleId"`               // 应用在 App Store 中的唯一标识符。
+	BundleId                 string                   `json:"bundleId"`                 // 应用的 Bundle ID。
+	BundleVersion            string                   `json:"bundleVersion"`            // 标识应用构建版本的版本号。
+	Environment              Environment              `json:"environment"`              // 通知适用的服务器环境，沙箱或生产环境。
+	SignedTransactionInfo    string                   `json:"signedTransactionInfo"`    // App Store 签名的交易信息，JWS 格式。
+	SignedRenewalInfo        string                   `json:"signedRenewalInfo"`        // App Store 签名的订阅续订信息，JWS 格式。
+	Status                   SubscriptionStatus       `json:"status"`                   // 自动续订订阅在签名时的状态。
+	ConsumptionRequestReason ConsumptionRequestReason `json:"consumptionRequestReason"` // 客户申请退款的原因，仅出现在 CONSUMPTION_REQUEST 通知中。
+
+	TransactionInfo *JWSTransactionDecodedPayload `json:"-"` // 校验解码后的 SignedTransactionInfo，没有时为 nil。
+	RenewalInfo     *JWSRenewalInfoDecodedPayload `json:"-"` // 校验解码后的 SignedRenewalInfo，没有时为 nil。
+}
+
+// NotificationSummary 批量延长订阅续订日期请求的汇总信息
+type NotificationSummary struct {
+	RequestIdentifier      string      `json:"requestIdentifier"`      // 批量延长请求时提供的唯一标识符。
+	Environment            Environment `json:"environment"`            // 通知适用的服务器环境，沙箱或生产环境。
+	AppAppleId             int64       `json:"appAppleId"`             // 应用在 App Store 中的唯一标识符。
+	BundleId               string      `json:"bundleId"`               // 应用的 Bundle ID。
+	ProductId              string      `json:"productId"`              // 自动续订订阅的产品标识符。
+	StorefrontCountryCodes []string    `json:"storefrontCountryCodes"` // 批量延长请求适用的店面国家代码列表。
+	SucceededCount         int64       `json:"succeededCount"`         // 成功延长续订日期的订阅数量。
+	FailedCount            int64       `json:"failedCount"`            // 未能延长续订日期的订阅数量。
+}
+
+// ExternalPurchaseToken 外部购买令牌的信息
+type ExternalPurchaseToken struct {
+	ExternalPurchaseId string    `json:"externalPurchaseId"` // App Store 为外部购买令牌生成的唯一标识符，沙箱环境下以 SANDBOX 开头。
+	TokenCreationDate  Timestamp `json:"tokenCreationDate"`  // 令牌创建的 UNIX 时间（以毫秒为单位）。
+	AppAppleId         int64     `json:"appAppleId"`         // 应用在 App Store 中的唯一标识符。
+	BundleId           string    `json:"bundleId"`           // 应用的 Bundle ID。
+}
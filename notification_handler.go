@@ -0,0 +1,224 @@
+package apple
+
+import (
+	"context"
+	"github.com/zeromicro/go-zero/core/logx"
+	"hash/fnv"
+	"net/http"
+	"sync"
+)
+
+// NotificationCallback 处理一种类型的通知：transaction 和 renewalInfo 为通知中已校验解码的交易和续订信息，没有时为 nil。
+// 返回错误时 App Store 会按重试计划重新发送这条通知
+type NotificationCallback func(ctx context.Context, notification *ResponseBodyV2DecodedPayload,
+	transaction *JWSTransactionDecodedPayload, renewalInfo *JWSRenewalInfoDecodedPayload) error
+
+// NotificationHandler 接收 App Store 服务器通知 V2 的 http.Handler：校验通知后按类型调用对应的回调，
+// 没有设置对应回调时调用 OnNotification，都没有设置时忽略这条通知。
+// 处理成功返回 200；请求体无法解析或校验失败返回 400；没有设置 Verifier 或回调返回错误时返回 500，App Store 会稍后重试
+type NotificationHandler struct {
+	Verifier *SignedDataVerifier
+
+	OnSubscribed             NotificationCallback // 订阅或重新订阅
+	OnDidRenew               NotificationCallback // 续订成功
+	OnExpired                NotificationCallback // 订阅过期
+	OnDidFailToRenew         NotificationCallback // 续订失败，可能进入计费重试或宽限期
+	OnGracePeriodExpired     NotificationCallback // 宽限期结束，仍未续订成功
+	OnDidChangeRenewalPref   NotificationCallback // 客户变更了续订的产品（升级、降级）
+	OnDidChangeRenewalStatus NotificationCallback // 客户开启或关闭了自动续订
+	OnOfferRedeemed          NotificationCallback // 客户兑换了优惠
+	OnPriceIncrease          NotificationCallback // 订阅涨价
+	OnRefund                 NotificationCallback // 交易已退款
+	OnRefundDeclined         NotificationCallback // 退款申请被拒绝
+	OnRefundReversed         NotificationCallback // 退款被撤销
+	OnConsumptionRequest     NotificationCallback // 客户申请退款，需要发送消耗信息
+	OnRenewalExtended        NotificationCallback // 订阅的续订日期已延长
+	OnRevoke                 NotificationCallback // 家庭共享的购买已被撤销
+	OnOneTimeCharge          NotificationCallback // 消耗型、非消耗型或非续订订阅的购买
+	OnNotification           NotificationCallback // 没有设置对应回调的其他通知，例如 RENEWAL_EXTENSION、EXTERNAL_PURCHASE_TOKEN
+
+	// TestNotifications 不为空时，收到的 TEST 通知会报告给它，供 Client.SendTestNotificationAndWait 使用
+	TestNotifications *TestNotificationWaiter
+
+	// Store 不为空时对通知去重和排序：已处理过的 notificationUUID 不再调用回调；
+	// 同一个订阅（originalTransactionId）中，signedDate 早于已处理的订阅状态通知（DID_RENEW、EXPIRED 等）的
+	// 订阅状态通知会被丢弃，避免例如迟到的 DID_RENEW 覆盖已处理的 EXPIRED。
+	// REFUND、REVOKE、CONSUMPTION_REQUEST 等事件通知不会覆盖订阅状态，总是调用回调。回调成功后才会记录通知已处理
+	Store NotificationStore
+
+	locks [64]sync.Mutex // 按 originalTransactionId 串行处理同一个订阅的通知
+}
+
+// NewNotificationHandler 创建通知处理程序，verifier 用于校验通知
+func NewNotificationHandler(verifier *SignedDataVerifier) *NotificationHandler {
+	return &NotificationHandler{Verifier: verifier}
+}
+
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Verifier == nil {
+		logx.Error("failed to verify app store notification: notification handler has no SignedDataVerifier")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	notification, err := h.Verifier.VerifyAndDecodeResponseBodyV2(r.Body)
+	if err != nil {
+		logx.Errorf("failed to verify app store notification: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err = h.Handle(r.Context(), notification); err != nil {
+		logx.Errorf("failed to handle app store notification %s (%s): %v",
+			notification.NotificationUUID, notification.NotificationType, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handle 按类型调用已校验通知的回调，设置了 Store 时先去重和排序，重复和过期的通知返回 nil
+func (h *NotificationHandler) Handle(ctx context.Context, notification *ResponseBodyV2DecodedPayload) error {
+	if h.Store == nil {
+		return h.dispatch(ctx, notification)
+	}
+
+	originalTransactionId := notificationOriginalTransactionId(notification)
+	key := originalTransactionId
+	if key == "" {
+		key = notification.NotificationUUID
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	mu := &h.locks[hash.Sum32()%uint32(len(h.locks))]
+	mu.Lock()
+	defer mu.Unlock()
+
+	processed, err := h.Store.Processed(ctx, notification.NotificationUUID)
+	if err != nil {
+		return err
+	}
+	if processed {
+		logx.Infof("skipped duplicate app store notification %s (%s)", notification.NotificationUUID, notification.NotificationType)
+		return nil
+	}
+
+	// 只有订阅状态通知参与排序，事件通知既不会因过期被丢弃，也不会推进订阅的 signedDate
+	var subscription string
+	if isSubscriptionStateNotification(notification.NotificationType) {
+		subscription = originalTransactionId
+	}
+	if subscription != "" {
+		lastSignedDate, ok, err := h.Store.LastSignedDate(ctx, subscription)
+		if err != nil {
+			return err
+		}
+		if ok && notification.SignedDate < lastSignedDate {
+			logx.Infof("skipped stale app store notification %s (%s) of subscription %s",
+				notification.NotificationUUID, notification.NotificationType, subscription)
+			return h.Store.Commit(ctx, notification.NotificationUUID, subscription, notification.SignedDate)
+		}
+	}
+
+	if err = h.dispatch(ctx, notification); err != nil {
+		return err
+	}
+	return h.Store.Commit(ctx, notification.NotificationUUID, subscription, notification.SignedDate)
+}
+
+// isSubscriptionStateNotification 是否为描述订阅当前状态的通知，较新的同类通知会覆盖较旧的
+func isSubscriptionStateNotification(notificationType NotificationTypeV2) bool {
+	switch notificationType {
+	case NotificationTypeSubscribed,
+		NotificationTypeDidRenew,
+		NotificationTypeExpired,
+		NotificationTypeDidFailToRenew,
+		NotificationTypeGracePeriodExpired,
+		NotificationTypeDidChangeRenewalPref,
+		NotificationTypeDidChangeRenewalStatus,
+		NotificationTypeRenewalExtended:
+		return true
+	}
+	return false
+}
+
+// notificationOriginalTransactionId 返回通知所属订阅的原始交易ID，没有交易信息时返回空
+func notificationOriginalTransactionId(notification *ResponseBodyV2DecodedPayload) string {
+	if notification.Data == nil {
+		return ""
+	}
+	if transaction := notification.Data.TransactionInfo; transaction != nil {
+		return transaction.OriginalTransactionId
+	}
+	if renewalInfo := notification.Data.RenewalInfo; renewalInfo != nil {
+		return renewalInfo.OriginalTransactionId
+	}
+	return ""
+}
+
+func (h *NotificationHandler) dispatch(ctx context.Context, notification *ResponseBodyV2DecodedPayload) error {
+	if notification.NotificationType == NotificationTypeTest && h.TestNotifications != nil {
+		h.TestNotifications.Notify(notification)
+	}
+
+	callback := h.callback(notification.NotificationType)
+	if callback == nil {
+		callback = h.OnNotification
+	}
+	if callback == nil {
+		logx.Infof("ignored app store notification %s (%s)", notification.NotificationUUID, notification.NotificationType)
+		return nil
+	}
+
+	var (
+		transaction *JWSTransactionDecodedPayload
+		renewalInfo *JWSRenewalInfoDecodedPayload
+	)
+	if notification.Data != nil {
+		transaction, renewalInfo = notification.Data.TransactionInfo, notification.Data.RenewalInfo
+	}
+	return callback(ctx, notification, transaction, renewalInfo)
+}
+
+func (h *NotificationHandler) callback(notificationType NotificationTypeV2) NotificationCallback {
+	switch notificationType {
+	case NotificationTypeSubscribed:
+		return h.OnSubscribed
+	case NotificationTypeDidRenew:
+		return h.OnDidRenew
+	case NotificationTypeExpired:
+		return h.OnExpired
+	case NotificationTypeDidFailToRenew:
+		return h.OnDidFailToRenew
+	case NotificationTypeGracePeriodExpired:
+		return h.OnGracePeriodExpired
+	case NotificationTypeDidChangeRenewalPref:
+		return h.OnDidChangeRenewalPref
+	case NotificationTypeDidChangeRenewalStatus:
+		return h.OnDidChangeRenewalStatus
+	case NotificationTypeOfferRedeemed:
+		return h.OnOfferRedeemed
+	case NotificationTypePriceIncrease:
+		return h.OnPriceIncrease
+	case NotificationTypeRefund:
+		return h.OnRefund
+	case NotificationTypeRefundDeclined:
+		return h.OnRefundDeclined
+	case NotificationTypeRefundReversed:
+		return h.OnRefundReversed
+	case NotificationTypeConsumptionRequest:
+		return h.OnConsumptionRequest
+	case NotificationTypeRenewalExtended:
+		return h.OnRenewalExtended
+	case NotificationTypeRevoke:
+		return h.OnRevoke
+	case NotificationTypeOneTimeCharge:
+		return h.OnOneTimeCharge
+	}
+	return nil
+}
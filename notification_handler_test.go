@@ -0,0 +1,337 @@
+package apple
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newTestNotification 返回 App Store 发送的通知请求体，data 中带有 originalTransactionId 的签名交易
+func newTestNotification(t *testing.T, p *testPKI, notificationType NotificationTypeV2, notificationUUID, originalTransactionId string, signedDate time.Time) string {
+	t.Helper()
+	transaction := p.sign(t, jwt.MapClaims{
+		"transactionId":         originalTransactionId,
+		"originalTransactionId": originalTransactionId,
+		"bundleId":              "com.example",
+		"environment":           "Sandbox",
+		"signedDate":            signedDate.UnixMilli(),
+	})
+	payload := p.sign(t, jwt.MapClaims{
+		"notificationType": string(notificationType),
+		"notificationUUID": notificationUUID,
+		"version":          "2.0",
+		"signedDate":       signedDate.UnixMilli(),
+		"data": map[string]any{
+			"bundleId":              "com.example",
+			"environment":           "Sandbox",
+			"signedTransactionInfo": transaction,
+		},
+	})
+	body, err := json.Marshal(ResponseBodyV2{SignedPayload: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func newTestNotificationVerifier(t *testing.T, p *testPKI) *SignedDataVerifier {
+	t.Helper()
+	verifier, err := NewSignedDataVerifier(&Config{Sandbox: true, Bid: "com.example"}, 0, NewTrustStore(p.root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+func TestNotificationHandlerServeHTTP(t *testing.T) {
+	p := newTestPKI(t, "")
+	now := time.Now()
+	notification := func(notificationType NotificationTypeV2, notificationUUID string, signedDate time.Duration) string {
+		return newTestNotification(t, p, notificationType, notificationUUID, "1000000000000001", now.Add(signedDate))
+	}
+
+	type request struct {
+		method     string // 为空时为 POST
+		body       string
+		wantStatus int
+	}
+	tests := []struct {
+		name       string
+		noVerifier bool
+		noStore    bool
+		failOnce   string // 第一次处理这个 notificationUUID 时回调返回错误
+		requests   []request
+		wantCalls  []string
+	}{
+		{
+			name: "duplicate notificationUUID",
+			requests: []request{
+				{body: notification(NotificationTypeSubscribed, "uuid-1", 0), wantStatus: http.StatusOK},
+				{body: notification(NotificationTypeSubscribed, "uuid-1", 0), wantStatus: http.StatusOK},
+			},
+			wantCalls: []string{"SUBSCRIBED uuid-1"},
+		},
+		{
+			name:    "duplicates are dispatched without store",
+			noStore: true,
+			requests: []request{
+				{body: notification(NotificationTypeSubscribed, "uuid-1", 0), wantStatus: http.StatusOK},
+				{body: notification(NotificationTypeSubscribed, "uuid-1", 0), wantStatus: http.StatusOK},
+			},
+			wantCalls: []string{"SUBSCRIBED uuid-1", "SUBSCRIBED uuid-1"},
+		},
+		{
+			name: "stale DID_RENEW after EXPIRED is dropped",
+			requests: []request{
+				{body: notification(NotificationTypeExpired, "uuid-2", 2*time.Minute), wantStatus: http.StatusOK},
+				{body: notification(NotificationTypeDidRenew, "uuid-1", time.Minute), wantStatus: http.StatusOK},
+				{body: notification(NotificationTypeDidRenew, "uuid-1", time.Minute), wantStatus: http.StatusOK},
+			},
+			wantCalls: []string{"EXPIRED uuid-2"},
+		},
+		{
+			name: "event notifications are always dispatched",
+			requests: []request{
+				{body: notification(NotificationTypeExpired, "uuid-2", 2*time.Minute), wantStatus: http.StatusOK},
+				{body: notification(NotificationTypeRefund, "uuid-1", time.Minute), wantStatus: http.StatusOK},
+				{body: notification(NotificationTypeConsumptionRequest, "uuid-4", 4*time.Minute), wantStatus: http.StatusOK},
+				// 事件通知不会推进订阅的 signedDate
+				{body: notification(NotificationTypeDidRenew, "uuid-3", 3*time.Minute), wantStatus: http.StatusOK},
+			},
+			wantCalls: []string{"EXPIRED uuid-2", "REFUND uuid-1", "CONSUMPTION_REQUEST uuid-4", "DID_RENEW uuid-3"},
+		},
+		{
+			name:     "callback error is retried",
+			failOnce: "uuid-1",
+			requests: []request{
+				{body: notification(NotificationTypeDidRenew, "uuid-1", 0), wantStatus: http.StatusInternalServerError},
+				{body: notification(NotificationTypeDidRenew, "uuid-1", 0), wantStatus: http.StatusOK},
+			},
+			wantCalls: []string{"DID_RENEW uuid-1", "DID_RENEW uuid-1"},
+		},
+		{
+			name:     "method not allowed",
+			requests: []request{{method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed}},
+		},
+		{
+			name: "invalid body",
+			requests: []request{
+				{body: "not json", wantStatus: http.StatusBadRequest},
+				{body: `{}`, wantStatus: http.StatusBadRequest},
+				{body: newTestNotification(t, newTestPKI(t, ""), NotificationTypeDidRenew, "uuid-1", "1000000000000001", now), wantStatus: http.StatusBadRequest},
+			},
+		},
+		{
+			name:       "missing verifier",
+			noVerifier: true,
+			requests:   []request{{body: notification(NotificationTypeDidRenew, "uuid-1", 0), wantStatus: http.StatusInternalServerError}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewNotificationHandler(newTestNotificationVerifier(t, p))
+			if tt.noVerifier {
+				handler.Verifier = nil
+			}
+			if !tt.noStore {
+				handler.Store = NewMemoryNotificationStore(0)
+			}
+			var calls []string
+			failed := false
+			handler.OnNotification = func(_ context.Context, notification *ResponseBodyV2DecodedPayload, transaction *JWSTransactionDecodedPayload, _ *JWSRenewalInfoDecodedPayload) error {
+				calls = append(calls, fmt.Sprintf("%s %s", notification.NotificationType, notification.NotificationUUID))
+				if transaction == nil || transaction.OriginalTransactionId != "1000000000000001" {
+					t.Errorf("transaction = %+v", transaction)
+				}
+				if notification.NotificationUUID == tt.failOnce && !failed {
+					failed = true
+					return errors.New("database unavailable")
+				}
+				return nil
+			}
+
+			for i, request := range tt.requests {
+				method := request.method
+				if method == "" {
+					method = http.MethodPost
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(method, "/apple/notifications", strings.NewReader(request.body)))
+				if w.Code != request.wantStatus {
+					t.Fatalf("request %d: status = %d, want %d", i, w.Code, request.wantStatus)
+				}
+				if w.Code == http.StatusMethodNotAllowed && w.Header().Get("Allow") != http.MethodPost {
+					t.Fatalf("Allow = %q, want POST", w.Header().Get("Allow"))
+				}
+			}
+			if strings.Join(calls, ", ") != strings.Join(tt.wantCalls, ", ") {
+				t.Fatalf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNotificationHandlerLocks(t *testing.T) {
+	p := newTestPKI(t, "")
+	handler := NewNotificationHandler(newTestNotificationVerifier(t, p))
+	handler.Store = NewMemoryNotificationStore(0)
+
+	// 同一个订阅的通知串行处理
+	var active, maxActive atomic.Int32
+	handler.OnDidRenew = func(context.Context, *ResponseBodyV2DecodedPayload, *JWSTransactionDecodedPayload, *JWSRenewalInfoDecodedPayload) error {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	}
+	bodies := make([]string, 16)
+	for i := range bodies {
+		bodies[i] = newTestNotification(t, p, NotificationTypeDidRenew, fmt.Sprintf("uuid-%d", i), "1000000000000001", time.Now())
+	}
+	var wg sync.WaitGroup
+	for _, body := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/apple/notifications", strings.NewReader(body)))
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxActive.Load() != 1 {
+		t.Fatalf("%d notifications of one subscription were handled concurrently", maxActive.Load())
+	}
+
+	// 不同分片的订阅可以并发处理：第一个订阅的回调等待第二个订阅的回调
+	shard := func(key string) uint32 {
+		hash := fnv.New32a()
+		hash.Write([]byte(key))
+		return hash.Sum32() % uint32(len(handler.locks))
+	}
+	first, second := "2000000000000001", "2000000000000002"
+	if shard(first) == shard(second) {
+		t.Fatalf("%s and %s share a lock shard", first, second)
+	}
+	secondDone := make(chan struct{})
+	handler.OnSubscribed = func(_ context.Context, _ *ResponseBodyV2DecodedPayload, transaction *JWSTransactionDecodedPayload, _ *JWSRenewalInfoDecodedPayload) error {
+		if transaction.OriginalTransactionId == second {
+			close(secondDone)
+			return nil
+		}
+		select {
+		case <-secondDone:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("notification of another subscription was blocked")
+		}
+	}
+	for _, id := range []string{first, second} {
+		wg.Add(1)
+		body := newTestNotification(t, p, NotificationTypeSubscribed, "uuid-"+id, id, time.Now())
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/apple/notifications", strings.NewReader(body)))
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMemoryNotificationStoreRetention(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryNotificationStore(20 * time.Millisecond)
+	if err := store.Commit(ctx, "uuid-1", "1000000000000001", 100); err != nil {
+		t.Fatal(err)
+	}
+	if processed, _ := store.Processed(ctx, "uuid-1"); !processed {
+		t.Fatal("uuid-1 is not processed")
+	}
+	if signedDate, ok, _ := store.LastSignedDate(ctx, "1000000000000001"); !ok || signedDate != 100 {
+		t.Fatalf("LastSignedDate() = %d, %v, want 100", signedDate, ok)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if processed, _ := store.Processed(ctx, "uuid-1"); processed {
+		t.Fatal("expired uuid-1 is still processed")
+	}
+	if _, ok, _ := store.LastSignedDate(ctx, "1000000000000001"); ok {
+		t.Fatal("expired subscription record is still returned")
+	}
+
+	// 过期的记录在下一次 Commit 时清理
+	if err := store.Commit(ctx, "uuid-2", "", 200); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.records.Notifications) != 1 || len(store.records.Subscriptions) != 0 {
+		t.Fatalf("records = %+v, want only uuid-2", store.records)
+	}
+}
+
+func TestFileNotificationStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "notifications.json")
+	store, err := NewFileNotificationStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Commit(ctx, "uuid-2", "1000000000000001", 200); err != nil {
+		t.Fatal(err)
+	}
+	// 较旧的通知不会回退订阅的 signedDate
+	if err = store.Commit(ctx, "uuid-1", "1000000000000001", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileNotificationStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uuid := range []string{"uuid-1", "uuid-2"} {
+		if processed, _ := reopened.Processed(ctx, uuid); !processed {
+			t.Fatalf("%s is not processed after reload", uuid)
+		}
+	}
+	if signedDate, ok, _ := reopened.LastSignedDate(ctx, "1000000000000001"); !ok || signedDate != 200 {
+		t.Fatalf("LastSignedDate() = %d, %v, want 200", signedDate, ok)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("store left %d files behind", len(entries))
+	}
+
+	if err = os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewFileNotificationStore(path, 0); err == nil {
+		t.Fatal("NewFileNotificationStore() accepted a corrupt file")
+	}
+}
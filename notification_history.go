@@ -0,0 +1,110 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// NotificationHistoryRequest 查询通知历史的条件，StartDate 和 EndDate 必填，其他为空时不过滤
+type NotificationHistoryRequest struct {
+	StartDate           Timestamp          `json:"startDate"`                     // 只返回此时间（含）之后发送的通知，最早为 180 天前。
+	EndDate             Timestamp          `json:"endDate"`                       // 只返回此时间之前发送的通知。
+	NotificationType    NotificationTypeV2 `json:"notificationType,omitempty"`    // 只返回此类型的通知。
+	NotificationSubtype Subtype            `json:"notificationSubtype,omitempty"` // 只返回此子类型的通知，需要同时指定 NotificationType。
+	TransactionId       string             `json:"transactionId,omitempty"`       // 只返回此客户的交易相关的通知，不能与类型条件同时使用。
+	OnlyFailures        bool               `json:"onlyFailures,omitempty"`        // 只返回未能发送成功的通知。
+}
+
+// Validate 检查查询条件
+func (r *NotificationHistoryRequest) Validate() error {
+	if r.StartDate == 0 || r.EndDate == 0 {
+		return errors.New("startDate and endDate are required")
+	}
+	if r.StartDate >= r.EndDate {
+		return errors.New("startDate must be before endDate")
+	}
+	if r.NotificationSubtype != "" && r.NotificationType == "" {
+		return errors.New("notificationSubtype requires notificationType")
+	}
+	if r.TransactionId != "" && r.NotificationType != "" {
+		return errors.New("transactionId cannot be combined with notificationType")
+	}
+	return nil
+}
+
+// SendAttemptItem App Store 发送一次通知的结果
+type SendAttemptItem struct {
+	AttemptDate       Timestamp         `json:"attemptDate"`       // 发送的时间。
+	SendAttemptResult SendAttemptResult `json:"sendAttemptResult"` // 发送的结果。
+}
+
+// NotificationHistoryResponseItem 通知历史中的一条通知
+type NotificationHistoryResponseItem struct {
+	SignedPayload      string                        `json:"signedPayload"`      // App Store 签名的通知，JWS 格式。
+	SendAttemptResults []*SendAttemptItem            `json:"sendAttemptResults"` // 每一次发送的结果。
+	Notification       *ResponseBodyV2DecodedPayload `json:"-"`                  // 校验并解码后的通知，只有 NotificationHistory 会设置。
+}
+
+// NotificationHistoryResponse 通知历史的一页数据
+type NotificationHistoryResponse struct {
+	NotificationHistory []*NotificationHistoryResponseItem `json:"notificationHistory"` // 通知列表。
+	HasMore             bool                               `json:"hasMore"`             // 是否还有更多通知。
+	PaginationToken     string                             `json:"paginationToken"`     // 下一页请求需要带上的分页令牌。
+}
+
+// GetNotificationHistory 查询通知历史的一页（Get Notification History）：
+// paginationToken 为空时查询第一页，之后传入上一页返回的 PaginationToken
+func (c *Client) GetNotificationHistory(ctx context.Context, request *NotificationHistoryRequest, paginationToken string) (*NotificationHistoryResponse, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	endpoint := c.endpoint("/inApps/v1/notifications/history")
+	if paginationToken != "" {
+		endpoint += "?" + url.Values{"paginationToken": {paginationToken}}.Encode()
+	}
+
+	response := &NotificationHistoryResponse{}
+	if err := c.sendJSON(ctx, http.MethodPost, endpoint, request, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// NotificationHistory 遍历符合条件的全部通知，自动请求后续分页，并通过 Client.Verifier 校验解码每一条通知，
+// 可用于找回服务中断期间没有收到的通知；出错时产出 (nil, err) 后结束遍历
+func (c *Client) NotificationHistory(ctx context.Context, request *NotificationHistoryRequest) iter.Seq2[*NotificationHistoryResponseItem, error] {
+	return func(yield func(*NotificationHistoryResponseItem, error) bool) {
+		verifier, err := c.verifier()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		paginationToken := ""
+		for {
+			page, err := c.GetNotificationHistory(ctx, request, paginationToken)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, item := range page.NotificationHistory {
+				item.Notification, err = verifier.VerifyAndDecodeNotification(item.SignedPayload)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if !page.HasMore || page.PaginationToken == "" {
+				return
+			}
+			paginationToken = page.PaginationToken
+		}
+	}
+}
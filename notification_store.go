@@ -0,0 +1,197 @@
+package apple
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultNotificationRetention 未指定时处理记录的保存时间，覆盖 App Store 约 3 天的重试计划
+const DefaultNotificationRetention = 7 * 24 * time.Hour
+
+// NotificationStore 记录已处理的通知，供 NotificationHandler 去重和排序：
+// App Store 会重试发送通知，同一条通知（notificationUUID 相同）可能收到多次，同一个订阅的通知也可能乱序到达。
+// 多实例部署时应使用数据库或 Redis 实现此接口
+type NotificationStore interface {
+	// Processed 返回 notificationUUID 对应的通知是否已经处理过
+	Processed(ctx context.Context, notificationUUID string) (bool, error)
+	// LastSignedDate 返回订阅已处理的通知中最新的 signedDate，没有记录时 ok 为 false
+	LastSignedDate(ctx context.Context, originalTransactionId string) (signedDate Timestamp, ok bool, err error)
+	// Commit 记录通知已处理；originalTransactionId 不为空时，signedDate 比已记录的更新才更新订阅的记录
+	Commit(ctx context.Context, notificationUUID, originalTransactionId string, signedDate Timestamp) error
+}
+
+// notificationRecords 内存中的处理记录，过期的记录在 Commit 时清理
+type notificationRecords struct {
+	Notifications map[string]time.Time          `json:"notifications"` // notificationUUID -> 过期时间
+	Subscriptions map[string]subscriptionRecord `json:"subscriptions"` // originalTransactionId -> 最新的通知
+}
+
+type subscriptionRecord struct {
+	SignedDate Timestamp `json:"signedDate"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+func newNotificationRecords() *notificationRecords {
+	return &notificationRecords{
+		Notifications: map[string]time.Time{},
+		Subscriptions: map[string]subscriptionRecord{},
+	}
+}
+
+func (r *notificationRecords) processed(notificationUUID string, now time.Time) bool {
+	expiresAt, ok := r.Notifications[notificationUUID]
+	return ok && now.Before(expiresAt)
+}
+
+func (r *notificationRecords) lastSignedDate(originalTransactionId string, now time.Time) (Timestamp, bool) {
+	record, ok := r.Subscriptions[originalTransactionId]
+	if !ok || !now.Before(record.ExpiresAt) {
+		return 0, false
+	}
+	return record.SignedDate, true
+}
+
+func (r *notificationRecords) commit(notificationUUID, originalTransactionId string, signedDate Timestamp, now time.Time, retention time.Duration) {
+	for uuid, expiresAt := range r.Notifications {
+		if !now.Before(expiresAt) {
+			delete(r.Notifications, uuid)
+		}
+	}
+	for id, record := range r.Subscriptions {
+		if !now.Before(record.ExpiresAt) {
+			delete(r.Subscriptions, id)
+		}
+	}
+
+	expiresAt := now.Add(retention)
+	r.Notifications[notificationUUID] = expiresAt
+	if originalTransactionId == "" {
+		return
+	}
+	record, ok := r.Subscriptions[originalTransactionId]
+	if !ok || signedDate > record.SignedDate {
+		record.SignedDate = signedDate
+	}
+	record.ExpiresAt = expiresAt
+	r.Subscriptions[originalTransactionId] = record
+}
+
+// MemoryNotificationStore 保存在内存中的 NotificationStore，记录保存 retention 后过期，进程重启后失效
+type MemoryNotificationStore struct {
+	mu        sync.Mutex
+	records   *notificationRecords
+	retention time.Duration
+}
+
+// NewMemoryNotificationStore 创建内存中的 NotificationStore，retention 小于等于 0 时为 DefaultNotificationRetention
+func NewMemoryNotificationStore(retention time.Duration) *MemoryNotificationStore {
+	if retention <= 0 {
+		retention = DefaultNotificationRetention
+	}
+	return &MemoryNotificationStore{records: newNotificationRecords(), retention: retention}
+}
+
+func (s *MemoryNotificationStore) Processed(_ context.Context, notificationUUID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records.processed(notificationUUID, time.Now()), nil
+}
+
+func (s *MemoryNotificationStore) LastSignedDate(_ context.Context, originalTransactionId string) (Timestamp, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	signedDate, ok := s.records.lastSignedDate(originalTransactionId, time.Now())
+	return signedDate, ok, nil
+}
+
+func (s *MemoryNotificationStore) Commit(_ context.Context, notificationUUID, originalTransactionId string, signedDate Timestamp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records.commit(notificationUUID, originalTransactionId, signedDate, time.Now(), s.retention)
+	return nil
+}
+
+// FileNotificationStore 保存在本地 JSON 文件中的 NotificationStore，进程重启后仍然有效。
+// 每次 Commit 都会重写整个文件，适合单实例、通知量不大的服务
+type FileNotificationStore struct {
+	mu        sync.Mutex
+	path      string
+	records   *notificationRecords
+	retention time.Duration
+}
+
+// NewFileNotificationStore 打开 path 指向的记录文件，文件不存在时在第一次 Commit 时创建；
+// retention 小于等于 0 时为 DefaultNotificationRetention
+func NewFileNotificationStore(path string, retention time.Duration) (*FileNotificationStore, error) {
+	if retention <= 0 {
+		retention = DefaultNotificationRetention
+	}
+	records := newNotificationRecords()
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read notification store: %v", err)
+	default:
+		if err = json.Unmarshal(data, records); err != nil {
+			return nil, fmt.Errorf("failed to parse notification store %s: %v", path, err)
+		}
+		if records.Notifications == nil {
+			records.Notifications = map[string]time.Time{}
+		}
+		if records.Subscriptions == nil {
+			records.Subscriptions = map[string]subscriptionRecord{}
+		}
+	}
+	return &FileNotificationStore{path: path, records: records, retention: retention}, nil
+}
+
+func (s *FileNotificationStore) Processed(_ context.Context, notificationUUID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records.processed(notificationUUID, time.Now()), nil
+}
+
+func (s *FileNotificationStore) LastSignedDate(_ context.Context, originalTransactionId string) (Timestamp, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	signedDate, ok := s.records.lastSignedDate(originalTransactionId, time.Now())
+	return signedDate, ok, nil
+}
+
+func (s *FileNotificationStore) Commit(_ context.Context, notificationUUID, originalTransactionId string, signedDate Timestamp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records.commit(notificationUUID, originalTransactionId, signedDate, time.Now(), s.retention)
+	return s.save()
+}
+
+// save 先写入临时文件再重命名，避免写入中途失败时损坏记录文件
+func (s *FileNotificationStore) save() error {
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save notification store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save notification store: %v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save notification store: %v", err)
+	}
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save notification store: %v", err)
+	}
+	return nil
+}
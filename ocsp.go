@@ -0,0 +1,158 @@
+package apple
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ocsp"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RevocationError 证书链中的证书已被吊销，或无法通过 OCSP 确认其吊销状态
+type RevocationError struct {
+	Certificate *x509.Certificate // 检查的证书
+	Status      int               // OCSP 状态：ocsp.Good、ocsp.Revoked 或 ocsp.Unknown，查询失败时为 ocsp.Unknown
+	RevokedAt   time.Time         // 证书被吊销的时间，仅在 Status 为 ocsp.Revoked 时有值
+	Reason      int               // 吊销原因（RFC 5280 CRLReason），仅在 Status 为 ocsp.Revoked 时有值
+	Err         error             // 查询或解析 OCSP 响应失败的原因
+}
+
+func (e *RevocationError) Error() string {
+	subject := e.Certificate.Subject.CommonName
+	switch {
+	case e.Err != nil:
+		return fmt.Sprintf("failed to check revocation status of %q: %v", subject, e.Err)
+	case e.Status == ocsp.Revoked:
+		return fmt.Sprintf("certificate %q was revoked at %s (reason %d)", subject, e.RevokedAt.Format(time.RFC3339), e.Reason)
+	default:
+		return fmt.Sprintf("revocation status of certificate %q is unknown", subject)
+	}
+}
+
+func (e *RevocationError) Unwrap() error {
+	return e.Err
+}
+
+// defaultOCSPClient 未设置 HTTPClient 时使用，校验签名数据的调用没有 context，需要超时避免 OCSP 服务无响应时一直阻塞
+var defaultOCSPClient = &http.Client{Timeout: 10 * time.Second}
+
+// OCSPChecker 通过 OCSP 在线检查证书的吊销状态，
+// 响应按签发者和证书序列号缓存，直到响应中的 nextUpdate
+type OCSPChecker struct {
+	HTTPClient   *http.Client     // 为空时使用超时为 10 秒的默认客户端
+	ResponderURL string           // 非空时覆盖证书中的 OCSP 地址，一般用于测试
+	Now          func() time.Time // 为空时使用 time.Now
+
+	mu    sync.Mutex
+	cache map[string]*ocsp.Response
+}
+
+// NewOCSPChecker 创建 OCSP 吊销状态检查器
+func NewOCSPChecker() *OCSPChecker {
+	return &OCSPChecker{}
+}
+
+// Check 检查由 issuer 签发的 cert 是否已被吊销，证书状态不是 Good 时返回 *RevocationError
+func (c *OCSPChecker) Check(cert, issuer *x509.Certificate) error {
+	resp, err := c.response(cert, issuer)
+	if err != nil {
+		return &RevocationError{Certificate: cert, Status: ocsp.Unknown, Err: err}
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return &RevocationError{Certificate: cert, Status: ocsp.Revoked, RevokedAt: resp.RevokedAt, Reason: resp.RevocationReason}
+	default:
+		return &RevocationError{Certificate: cert, Status: resp.Status}
+	}
+}
+
+func (c *OCSPChecker) response(cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	key := fmt.Sprintf("%x/%s", issuer.RawSubject, cert.SerialNumber.Text(16))
+	now := c.now()
+
+	c.mu.Lock()
+	if resp, ok := c.cache[key]; ok {
+		if now.Before(resp.NextUpdate) {
+			c.mu.Unlock()
+			return resp, nil
+		}
+		delete(c.cache, key)
+	}
+	c.mu.Unlock()
+
+	resp, err := c.fetch(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if now.Before(resp.ThisUpdate.Add(-5 * time.Minute)) {
+		return nil, errors.New("OCSP response is not yet valid")
+	}
+	if !resp.NextUpdate.IsZero() && !now.Before(resp.NextUpdate) {
+		return nil, errors.New("OCSP response has expired")
+	}
+
+	// 没有 nextUpdate 的响应表示随时可能有新的状态，不缓存
+	if !resp.NextUpdate.IsZero() {
+		c.mu.Lock()
+		if c.cache == nil {
+			c.cache = make(map[string]*ocsp.Response)
+		}
+		c.cache[key] = resp
+		c.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (c *OCSPChecker) fetch(cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	server := c.ResponderURL
+	if server == "" {
+		if len(cert.OCSPServer) == 0 {
+			return nil, errors.New("certificate has no OCSP responder")
+		}
+		server = cert.OCSPServer[0]
+	}
+
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCSP request: %v", err)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = defaultOCSPClient
+	}
+	res, err := client.Post(server, "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OCSP responder returned %s", res.Status)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OCSP response: %v", err)
+	}
+	return resp, nil
+}
+
+func (c *OCSPChecker) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,140 @@
+package apple
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// newTestOCSPResponder 返回按 status 应答的 OCSP 服务和请求计数，响应由被查询证书的签发者签名
+func newTestOCSPResponder(t *testing.T, p **testPKI, status func(cert *x509.Certificate) int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		request, err := ocsp.ParseRequest(body)
+		if err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		pki := *p
+		cert, issuer, key := pki.intermediate, pki.root, crypto.Signer(pki.rootKey)
+		if request.SerialNumber.Cmp(pki.leaf.SerialNumber) == 0 {
+			cert, issuer, key = pki.leaf, pki.intermediate, pki.intermediateKey
+		}
+		now := time.Now()
+		response, err := ocsp.CreateResponse(issuer, issuer, ocsp.Response{
+			Status:           status(cert),
+			SerialNumber:     request.SerialNumber,
+			ThisUpdate:       now.Add(-time.Minute),
+			NextUpdate:       now.Add(time.Hour),
+			RevokedAt:        now.Add(-time.Hour),
+			RevocationReason: ocsp.KeyCompromise,
+		}, key)
+		if err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestOCSPCheckerGood(t *testing.T) {
+	var p *testPKI
+	server, requests := newTestOCSPResponder(t, &p, func(*x509.Certificate) int { return ocsp.Good })
+	p = newTestPKI(t, server.URL)
+
+	checker := NewOCSPChecker()
+	if err := checker.Check(p.leaf, p.intermediate); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if err := checker.Check(p.intermediate, p.root); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("requests = %d, want 2", got)
+	}
+}
+
+func TestOCSPCheckerRevoked(t *testing.T) {
+	var p *testPKI
+	server, _ := newTestOCSPResponder(t, &p, func(cert *x509.Certificate) int {
+		if cert == p.leaf {
+			return ocsp.Revoked
+		}
+		return ocsp.Good
+	})
+	p = newTestPKI(t, server.URL)
+
+	store := NewTrustStore(p.root)
+	store.EnableOnlineChecks(NewOCSPChecker())
+	_, err := store.verifyCertificateChain([]*x509.Certificate{p.leaf, p.intermediate}, time.Now())
+
+	var revocationErr *RevocationError
+	if !errors.As(err, &revocationErr) {
+		t.Fatalf("verifyCertificateChain() error = %v, want *RevocationError", err)
+	}
+	if revocationErr.Status != ocsp.Revoked || revocationErr.Certificate != p.leaf || revocationErr.Reason != ocsp.KeyCompromise {
+		t.Fatalf("RevocationError = %+v, want leaf revoked for key compromise", revocationErr)
+	}
+}
+
+func TestOCSPCheckerCache(t *testing.T) {
+	var p *testPKI
+	server, requests := newTestOCSPResponder(t, &p, func(*x509.Certificate) int { return ocsp.Good })
+	p = newTestPKI(t, server.URL)
+
+	now := time.Now()
+	checker := &OCSPChecker{Now: func() time.Time { return now }}
+	for i := 0; i < 3; i++ {
+		if err := checker.Check(p.leaf, p.intermediate); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("requests = %d, want 1 while the response is fresh", got)
+	}
+
+	// 超过 nextUpdate 后重新查询
+	now = now.Add(2 * time.Hour)
+	if err := checker.Check(p.leaf, p.intermediate); err == nil {
+		t.Fatal("Check() accepted a response past its nextUpdate")
+	}
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("requests = %d, want 2 after the cached response expired", got)
+	}
+}
+
+func TestOCSPCheckerResponderError(t *testing.T) {
+	p := newTestPKI(t, "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	checker := &OCSPChecker{ResponderURL: server.URL}
+	err := checker.Check(p.leaf, p.intermediate)
+	var revocationErr *RevocationError
+	if !errors.As(err, &revocationErr) || revocationErr.Status != ocsp.Unknown || revocationErr.Err == nil {
+		t.Fatalf("Check() error = %v, want unknown status with cause", err)
+	}
+}
@@ -0,0 +1,40 @@
+package apple
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// OrderLookupResponse 订单号查询的结果
+type OrderLookupResponse struct {
+	Status             OrderLookupStatus               `json:"status"`             // 订单号是否有效。
+	SignedTransactions []string                        `json:"signedTransactions"` // 订单中每笔应用内购买的签名交易信息，JWS 格式。
+	Transactions       []*JWSTransactionDecodedPayload `json:"-"`                  // 校验并解码后的交易信息，与 SignedTransactions 一一对应。
+}
+
+// LookUpOrderId 根据客户 App Store 收据邮件中的订单号查询订单中的全部应用内购买（Look Up Order ID）：
+// orderId 客户收据邮件中的订单号
+// 订单号无效时返回 Status 为 OrderLookupStatusInvalid 的结果而不是错误
+func (c *Client) LookUpOrderId(ctx context.Context, orderId string) (*OrderLookupResponse, error) {
+	verifier, err := c.verifier()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &OrderLookupResponse{}
+	err = c.send(ctx, http.MethodGet, c.endpoint("/inApps/v1/lookup/%s", url.PathEscape(orderId)), nil, response)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Transactions = make([]*JWSTransactionDecodedPayload, 0, len(response.SignedTransactions))
+	for _, signed := range response.SignedTransactions {
+		transaction, err := verifier.VerifyAndDecodeTransaction(signed)
+		if err != nil {
+			return nil, err
+		}
+		response.Transactions = append(response.Transactions, transaction)
+	}
+	return response, nil
+}
@@ -0,0 +1,294 @@
+package apple
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSHA1          = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+// maxBERDepth BER 数据允许的最大嵌套层数
+const maxBERDepth = 32
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      pkcs7ContentInfo
+	Certificates     asn1.RawValue     `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue     `asn1:"optional,tag:1"`
+	SignerInfos      []pkcs7SignerInfo `asn1:"set"`
+}
+
+type pkcs7IssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type pkcs7SignerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     pkcs7IssuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type pkcs7Attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+// pkcs7 解析后的 PKCS#7 SignedData
+type pkcs7 struct {
+	content      []byte              // 被签名的内容
+	certificates []*x509.Certificate // 携带的证书
+	signer       pkcs7SignerInfo     // 第一个签名者
+}
+
+// parsePKCS7 解析 BER 或 DER 编码的 PKCS#7 SignedData，不校验签名
+func parsePKCS7(data []byte) (*pkcs7, error) {
+	der, err := berToDER(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var info pkcs7ContentInfo
+	if _, err = asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse pkcs7 content info: %v", err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unsupported pkcs7 content type %s", info.ContentType)
+	}
+
+	var signedData pkcs7SignedData
+	if _, err = asn1.Unmarshal(info.Content.Bytes, &signedData); err != nil {
+		return nil, fmt.Errorf("failed to parse pkcs7 signed data: %v", err)
+	}
+	if len(signedData.SignerInfos) == 0 {
+		return nil, errors.New("pkcs7 signed data has no signer")
+	}
+
+	var content []byte
+	if _, err = asn1.Unmarshal(signedData.ContentInfo.Content.Bytes, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse pkcs7 content: %v", err)
+	}
+
+	var certificates []*x509.Certificate
+	if len(signedData.Certificates.Bytes) > 0 {
+		if certificates, err = x509.ParseCertificates(signedData.Certificates.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to parse pkcs7 certificates: %v", err)
+		}
+	}
+
+	return &pkcs7{
+		content:      content,
+		certificates: certificates,
+		signer:       signedData.SignerInfos[0],
+	}, nil
+}
+
+// signerCertificate 返回签名者的证书，以及签发它的中间证书
+func (p *pkcs7) signerCertificate() (leaf, intermediate *x509.Certificate, err error) {
+	id := p.signer.IssuerAndSerialNumber
+	for _, cert := range p.certificates {
+		if cert.SerialNumber.Cmp(id.SerialNumber) == 0 && bytes.Equal(cert.RawIssuer, id.Issuer.FullBytes) {
+			leaf = cert
+			break
+		}
+	}
+	if leaf == nil {
+		return nil, nil, fmt.Errorf("%w: signer certificate not found", ErrInvalidCertificateChain)
+	}
+	for _, cert := range p.certificates {
+		if cert != leaf && bytes.Equal(cert.RawSubject, leaf.RawIssuer) {
+			return leaf, cert, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: intermediate certificate not found", ErrInvalidCertificateChain)
+}
+
+// verifySignature 使用签名者证书校验内容的签名
+func (p *pkcs7) verifySignature(leaf *x509.Certificate) error {
+	var hash crypto.Hash
+	switch algorithm := p.signer.DigestAlgorithm.Algorithm; {
+	case algorithm.Equal(oidSHA1):
+		hash = crypto.SHA1
+	case algorithm.Equal(oidSHA256):
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("unsupported pkcs7 digest algorithm %s", algorithm)
+	}
+
+	signed := p.content
+	if len(p.signer.AuthenticatedAttributes.Bytes) > 0 {
+		// 有签名属性时签名的是属性（以 SET 编码），属性中的 messageDigest 为内容的摘要
+		signed = append([]byte{0x31}, p.signer.AuthenticatedAttributes.FullBytes[1:]...)
+		var attributes []pkcs7Attribute
+		if _, err := asn1.UnmarshalWithParams(signed, &attributes, "set"); err != nil {
+			return fmt.Errorf("failed to parse pkcs7 authenticated attributes: %v", err)
+		}
+		var digest []byte
+		for _, attribute := range attributes {
+			if attribute.Type.Equal(oidMessageDigest) {
+				if _, err := asn1.Unmarshal(attribute.Value.Bytes, &digest); err != nil {
+					return fmt.Errorf("failed to parse pkcs7 message digest: %v", err)
+				}
+			}
+		}
+		h := hash.New()
+		h.Write(p.content)
+		if !bytes.Equal(digest, h.Sum(nil)) {
+			return errors.New("pkcs7 message digest mismatch")
+		}
+	}
+
+	var algorithm x509.SignatureAlgorithm
+	switch leaf.PublicKeyAlgorithm {
+	case x509.RSA:
+		algorithm = map[crypto.Hash]x509.SignatureAlgorithm{crypto.SHA1: x509.SHA1WithRSA, crypto.SHA256: x509.SHA256WithRSA}[hash]
+	case x509.ECDSA:
+		algorithm = map[crypto.Hash]x509.SignatureAlgorithm{crypto.SHA1: x509.ECDSAWithSHA1, crypto.SHA256: x509.ECDSAWithSHA256}[hash]
+	default:
+		return fmt.Errorf("unsupported pkcs7 signer key algorithm %s", leaf.PublicKeyAlgorithm)
+	}
+	if err := leaf.CheckSignature(algorithm, signed, p.signer.EncryptedDigest); err != nil {
+		return fmt.Errorf("invalid pkcs7 signature: %v", err)
+	}
+	return nil
+}
+
+// berToDER 将 BER 编码（例如不定长度、分段的 OCTET STRING）转换为 encoding/asn1 可以解析的 DER 编码
+func berToDER(ber []byte) ([]byte, error) {
+	der, _, err := readBER(ber, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ber encoding: %v", err)
+	}
+	return der, nil
+}
+
+// readBER 读取一个 BER 元素，返回它的 DER 编码和剩余的数据
+func readBER(data []byte, depth int) (der, rest []byte, err error) {
+	if depth > maxBERDepth {
+		return nil, nil, errors.New("too deeply nested")
+	}
+	if len(data) < 2 {
+		return nil, nil, errors.New("truncated element")
+	}
+
+	// 标签，高位标签号以多个字节表示
+	i := 1
+	if data[0]&0x1f == 0x1f {
+		for ; i < len(data) && data[i]&0x80 != 0; i++ {
+		}
+		i++
+	}
+	if i >= len(data) {
+		return nil, nil, errors.New("truncated tag")
+	}
+	tag, constructed := data[:i], data[0]&0x20 != 0
+
+	// 长度，0x80 表示不定长度，以 00 00 结束
+	length, indefinite := 0, false
+	switch b := data[i]; {
+	case b == 0x80:
+		if !constructed {
+			return nil, nil, errors.New("indefinite length for primitive element")
+		}
+		indefinite = true
+		i++
+	case b&0x80 == 0:
+		length = int(b)
+		i++
+	default:
+		n := int(b & 0x7f)
+		if n > 4 || i+1+n > len(data) {
+			return nil, nil, errors.New("invalid length")
+		}
+		for _, lb := range data[i+1 : i+1+n] {
+			length = length<<8 | int(lb)
+		}
+		i += 1 + n
+	}
+	if !indefinite && (length < 0 || i+length > len(data)) {
+		return nil, nil, errors.New("truncated content")
+	}
+
+	if !constructed {
+		return encodeDER(tag, data[i:i+length]), data[i+length:], nil
+	}
+
+	body := data[i:]
+	if !indefinite {
+		body = data[i : i+length]
+	}
+	var children [][]byte
+	for {
+		if indefinite {
+			if len(body) < 2 {
+				return nil, nil, errors.New("missing end-of-contents")
+			}
+			if body[0] == 0 && body[1] == 0 {
+				body = body[2:]
+				break
+			}
+		} else if len(body) == 0 {
+			break
+		}
+		var child []byte
+		if child, body, err = readBER(body, depth+1); err != nil {
+			return nil, nil, err
+		}
+		children = append(children, child)
+	}
+	if !indefinite {
+		body = data[i+length:]
+	}
+
+	// 分段的 OCTET STRING 合并为一个基本类型的 OCTET STRING
+	if len(tag) == 1 && tag[0] == 0x24 {
+		var content []byte
+		for _, child := range children {
+			var raw asn1.RawValue
+			if _, err = asn1.Unmarshal(child, &raw); err != nil {
+				return nil, nil, err
+			}
+			content = append(content, raw.Bytes...)
+		}
+		return encodeDER([]byte{0x04}, content), body, nil
+	}
+	return encodeDER(tag, bytes.Join(children, nil)), body, nil
+}
+
+// encodeDER 以定长格式编码一个元素
+func encodeDER(tag, content []byte) []byte {
+	der := append([]byte(nil), tag...)
+	switch n := len(content); {
+	case n < 0x80:
+		der = append(der, byte(n))
+	default:
+		var length []byte
+		for ; n > 0; n >>= 8 {
+			length = append([]byte{byte(n)}, length...)
+		}
+		der = append(der, 0x80|byte(len(length)))
+		der = append(der, length...)
+	}
+	return append(der, content...)
+}
@@ -0,0 +1,290 @@
+package apple
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	oidData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// testReceipt 生成与 Apple 收据相同结构的 BER 编码 PKCS#7：外层不定长度，内容为分段的 OCTET STRING
+type testReceipt struct {
+	content    []byte // 收据内容，即 SET OF ReceiptAttribute 的 DER 编码
+	signed     []byte // 计算签名时使用的内容，为 nil 时为 content，用于模拟内容被篡改
+	attributes bool   // 是否使用签名属性，此时签名的是属性而不是内容
+	noDigest   bool   // 签名属性中不带 messageDigest
+}
+
+func (r testReceipt) encode(t *testing.T, p *testPKI) string {
+	t.Helper()
+	signed := r.signed
+	if signed == nil {
+		signed = r.content
+	}
+	digest := sha256.Sum256(signed)
+
+	var authenticatedAttributes asn1.RawValue
+	message := digest[:]
+	if r.attributes {
+		attributes := []pkcs7Attribute{{Type: oidContentType, Value: asn1.RawValue{FullBytes: mustMarshal(t, []asn1.ObjectIdentifier{oidData}, "set")}}}
+		if !r.noDigest {
+			attributes = append(attributes, pkcs7Attribute{Type: oidMessageDigest, Value: asn1.RawValue{FullBytes: mustMarshal(t, [][]byte{digest[:]}, "set")}})
+		}
+		set := mustMarshal(t, attributes, "set")
+		h := sha256.Sum256(set)
+		message = h[:]
+		// 签名属性以 [0] IMPLICIT 编码
+		authenticatedAttributes = asn1.RawValue{FullBytes: append([]byte{0xa0}, set[1:]...)}
+	}
+	signature, err := ecdsa.SignASN1(rand.Reader, p.leafKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := berIndefinite(0x30, mustMarshal(t, oidData, ""), berIndefinite(0xa0, berChunkedOctets(r.content, 16)))
+	signedData := mustMarshal(t, struct {
+		Version          int
+		DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+		ContentInfo      asn1.RawValue
+		Certificates     asn1.RawValue
+		SignerInfos      []pkcs7SignerInfo `asn1:"set"`
+	}{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		ContentInfo:      asn1.RawValue{FullBytes: content},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: append(append([]byte{}, p.leaf.Raw...), p.intermediate.Raw...)},
+		SignerInfos: []pkcs7SignerInfo{{
+			Version:                   1,
+			IssuerAndSerialNumber:     pkcs7IssuerAndSerial{Issuer: asn1.RawValue{FullBytes: p.leaf.RawIssuer}, SerialNumber: p.leaf.SerialNumber},
+			DigestAlgorithm:           pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			AuthenticatedAttributes:   authenticatedAttributes,
+			DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			EncryptedDigest:           signature,
+		}},
+	}, "")
+	return base64.StdEncoding.EncodeToString(berIndefinite(0x30, mustMarshal(t, oidSignedData, ""), berIndefinite(0xa0, signedData)))
+}
+
+func mustMarshal(t *testing.T, v any, params string) []byte {
+	t.Helper()
+	der, err := asn1.MarshalWithParams(v, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// berIndefinite 以不定长度编码一个结构类型的元素
+func berIndefinite(tag byte, children ...[]byte) []byte {
+	return append(append([]byte{tag, 0x80}, bytes.Join(children, nil)...), 0, 0)
+}
+
+// berChunkedOctets 将 data 编码为每段 size 字节的分段 OCTET STRING
+func berChunkedOctets(data []byte, size int) []byte {
+	var chunks [][]byte
+	for len(data) > size {
+		chunks = append(chunks, encodeDER([]byte{0x04}, data[:size]))
+		data = data[size:]
+	}
+	chunks = append(chunks, encodeDER([]byte{0x04}, data))
+	return berIndefinite(0x24, chunks...)
+}
+
+func receiptAttributes(t *testing.T, attributes ...receiptAttribute) []byte {
+	t.Helper()
+	return mustMarshal(t, attributes, "set")
+}
+
+func receiptUTF8(t *testing.T, typ int, s string) receiptAttribute {
+	return receiptAttribute{Type: typ, Version: 1, Value: mustMarshal(t, s, "utf8")}
+}
+
+func receiptIA5(t *testing.T, typ int, s string) receiptAttribute {
+	return receiptAttribute{Type: typ, Version: 1, Value: mustMarshal(t, s, "ia5")}
+}
+
+func receiptInt(t *testing.T, typ int, n int64) receiptAttribute {
+	return receiptAttribute{Type: typ, Version: 1, Value: mustMarshal(t, n, "")}
+}
+
+func TestVerifyAppReceipt(t *testing.T) {
+	p := newTestPKI(t, "")
+	store := NewTrustStore(p.root)
+	created := time.Now().UTC().Truncate(time.Second)
+	purchased := created.Add(-time.Hour)
+	expires := created.Add(30 * 24 * time.Hour)
+
+	inApp := receiptAttributes(t,
+		receiptInt(t, inAppAttributeQuantity, 1),
+		receiptUTF8(t, inAppAttributeProductId, "com.example.monthly"),
+		receiptUTF8(t, inAppAttributeTransactionId, "1000000000000002"),
+		receiptUTF8(t, inAppAttributeOriginalTransactionId, "1000000000000001"),
+		receiptIA5(t, inAppAttributePurchaseDate, purchased.Format(time.RFC3339)),
+		receiptIA5(t, inAppAttributeOriginalPurchaseDate, purchased.Add(-30*24*time.Hour).Format(time.RFC3339)),
+		receiptIA5(t, inAppAttributeExpiresDate, expires.Format(time.RFC3339)),
+		receiptInt(t, inAppAttributeWebOrderLineItemId, 2000000000000001),
+		receiptIA5(t, inAppAttributeCancellationDate, ""),
+		receiptInt(t, inAppAttributeIsTrialPeriod, 0),
+		receiptInt(t, inAppAttributeIsInIntroOfferPeriod, 1),
+		receiptUTF8(t, 9999, "unknown attributes are ignored"),
+	)
+	content := receiptAttributes(t,
+		receiptUTF8(t, receiptAttributeBundleId, "com.example"),
+		receiptUTF8(t, receiptAttributeApplicationVersion, "42"),
+		receiptUTF8(t, receiptAttributeOriginalApplicationVersion, "1.0"),
+		receiptIA5(t, receiptAttributeReceiptCreationDate, created.Format(time.RFC3339)),
+		receiptAttribute{Type: receiptAttributeInApp, Version: 1, Value: inApp},
+	)
+	tampered := bytes.Replace(content, []byte("com.example.monthly"), []byte("com.example.yearly!"), 1)
+
+	want := &AppReceipt{
+		BundleId:                   "com.example",
+		ApplicationVersion:         "42",
+		OriginalApplicationVersion: "1.0",
+		ReceiptCreationDate:        created,
+		InApp: []*AppReceiptInApp{{
+			Quantity:              1,
+			ProductId:             "com.example.monthly",
+			TransactionId:         "1000000000000002",
+			OriginalTransactionId: "1000000000000001",
+			PurchaseDate:          purchased,
+			OriginalPurchaseDate:  purchased.Add(-30 * 24 * time.Hour),
+			ExpiresDate:           expires,
+			WebOrderLineItemId:    2000000000000001,
+			IsInIntroOfferPeriod:  true,
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		receipt   testReceipt
+		store     *TrustStore
+		wantChain bool // 是否应为证书链错误
+		wantErr   bool
+	}{
+		{name: "signed content", receipt: testReceipt{content: content}, store: store},
+		{name: "signed attributes", receipt: testReceipt{content: content, attributes: true}, store: store},
+		{name: "tampered content", receipt: testReceipt{content: tampered, signed: content}, store: store, wantErr: true},
+		{name: "tampered content with signed attributes", receipt: testReceipt{content: tampered, signed: content, attributes: true}, store: store, wantErr: true},
+		{name: "missing messageDigest", receipt: testReceipt{content: content, attributes: true, noDigest: true}, store: store, wantErr: true},
+		{name: "wrong root", receipt: testReceipt{content: content}, store: NewTrustStore(newTestPKI(t, "").root), wantChain: true, wantErr: true},
+		{name: "default apple root", receipt: testReceipt{content: content}, wantChain: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.receipt.encode(t, p)
+			// 客户端上传的收据可能带有换行
+			encoded = encoded[:64] + "\n" + encoded[64:]
+
+			got, err := VerifyAppReceipt(tt.store, encoded)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("VerifyAppReceipt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantChain != errors.Is(err, ErrInvalidCertificateChain) {
+				t.Fatalf("VerifyAppReceipt() error = %v, want ErrInvalidCertificateChain %v", err, tt.wantChain)
+			}
+			if err != nil {
+				return
+			}
+			if got.BundleId != want.BundleId || got.ApplicationVersion != want.ApplicationVersion ||
+				got.OriginalApplicationVersion != want.OriginalApplicationVersion ||
+				!got.ReceiptCreationDate.Equal(want.ReceiptCreationDate) || !got.ExpirationDate.IsZero() {
+				t.Fatalf("receipt = %+v, want %+v", got, want)
+			}
+			if len(got.InApp) != 1 {
+				t.Fatalf("in-app = %d, want 1", len(got.InApp))
+			}
+			if g, w := got.InApp[0], want.InApp[0]; g.Quantity != w.Quantity || g.ProductId != w.ProductId ||
+				g.TransactionId != w.TransactionId || g.OriginalTransactionId != w.OriginalTransactionId ||
+				!g.PurchaseDate.Equal(w.PurchaseDate) || !g.OriginalPurchaseDate.Equal(w.OriginalPurchaseDate) ||
+				!g.ExpiresDate.Equal(w.ExpiresDate) || g.WebOrderLineItemId != w.WebOrderLineItemId ||
+				!g.CancellationDate.IsZero() || g.IsTrialPeriod != w.IsTrialPeriod || g.IsInIntroOfferPeriod != w.IsInIntroOfferPeriod {
+				t.Fatalf("in-app = %+v, want %+v", g, w)
+			}
+		})
+	}
+
+	// 不校验签名时篡改的内容也能解析
+	got, err := DecodeAppReceipt(testReceipt{content: tampered, signed: content}.encode(t, p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.InApp[0].ProductId != "com.example.yearly!" {
+		t.Fatalf("productId = %q", got.InApp[0].ProductId)
+	}
+}
+
+func TestBERToDER(t *testing.T) {
+	nested := func(depth int) []byte {
+		der := []byte{0x05, 0x00}
+		for range depth {
+			der = berIndefinite(0x30, der)
+		}
+		return der
+	}
+
+	tests := []struct {
+		name    string
+		ber     []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "der is unchanged", ber: []byte{0x30, 0x03, 0x02, 0x01, 0x07}, want: []byte{0x30, 0x03, 0x02, 0x01, 0x07}},
+		{name: "indefinite length", ber: []byte{0x30, 0x80, 0x02, 0x01, 0x07, 0x00, 0x00}, want: []byte{0x30, 0x03, 0x02, 0x01, 0x07}},
+		{
+			name: "chunked octet string",
+			ber:  []byte{0x24, 0x80, 0x04, 0x02, 'a', 'b', 0x04, 0x01, 'c', 0x00, 0x00},
+			want: []byte{0x04, 0x03, 'a', 'b', 'c'},
+		},
+		{
+			name: "definite chunked octet string",
+			ber:  []byte{0x24, 0x07, 0x04, 0x02, 'a', 'b', 0x04, 0x01, 'c'},
+			want: []byte{0x04, 0x03, 'a', 'b', 'c'},
+		},
+		{
+			name: "long form length",
+			ber:  append([]byte{0x04, 0x81, 0x80}, make([]byte, 0x80)...),
+			want: append([]byte{0x04, 0x81, 0x80}, make([]byte, 0x80)...),
+		},
+		{name: "nested within limit", ber: nested(maxBERDepth), want: func() []byte {
+			der := []byte{0x05, 0x00}
+			for range maxBERDepth {
+				der = encodeDER([]byte{0x30}, der)
+			}
+			return der
+		}()},
+		{name: "too deeply nested", ber: nested(maxBERDepth + 1), wantErr: true},
+		{name: "empty", ber: nil, wantErr: true},
+		{name: "truncated tag", ber: []byte{0x1f, 0x81}, wantErr: true},
+		{name: "truncated content", ber: []byte{0x04, 0x05, 'a'}, wantErr: true},
+		{name: "truncated long form length", ber: []byte{0x04, 0x82, 0x01}, wantErr: true},
+		{name: "length does not fit", ber: []byte{0x04, 0x84, 0x7f, 0xff, 0xff, 0xff, 0x00}, wantErr: true},
+		{name: "too many length bytes", ber: []byte{0x04, 0x85, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}, wantErr: true},
+		{name: "missing end-of-contents", ber: []byte{0x30, 0x80, 0x02, 0x01, 0x07}, wantErr: true},
+		{name: "indefinite primitive", ber: []byte{0x04, 0x80, 'a', 0x00, 0x00}, wantErr: true},
+		{name: "truncated child", ber: []byte{0x30, 0x03, 0x02, 0x05, 0x07}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := berToDER(tt.ber)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("berToDER() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("berToDER() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package apple
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PromotionalOfferSignature 客户端兑换推广优惠时传给 StoreKit 的参数
+type PromotionalOfferSignature struct {
+	KeyIdentifier string `json:"keyIdentifier"` // 签名使用的私钥 ID。
+	Nonce         string `json:"nonce"`         // 本次签名的随机 UUID，小写。
+	Timestamp     int64  `json:"timestamp"`     // 签名的 UNIX 时间（以毫秒为单位），24 小时内有效。
+	Signature     string `json:"signature"`     // Base64 编码的签名。
+}
+
+// PromotionalOfferSigner 为自动续订订阅的推广优惠生成签名，使用与 App Store Server API 相同的 Config
+type PromotionalOfferSigner struct {
+	keyIdentifier string
+	bundleId      string
+	privateKey    *ecdsa.PrivateKey
+}
+
+// NewPromotionalOfferSigner 创建推广优惠签名器：使用 Config.Kid、Config.Bid 和 Config.PrivateKey，
+// 私钥需要是 App Store Connect 中生成的 App 内购买项目密钥
+func NewPromotionalOfferSigner(config *Config) (*PromotionalOfferSigner, error) {
+	if config.Kid == "" || config.Bid == "" {
+		return nil, errors.New("key id and bundle id are required")
+	}
+	privateKey, err := parsePrivateKey(config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PromotionalOfferSigner{
+		keyIdentifier: config.Kid,
+		bundleId:      config.Bid,
+		privateKey:    privateKey,
+	}, nil
+}
+
+// Sign 使用新的 nonce 和当前时间为推广优惠签名：
+// productId 订阅的产品标识符
+// offerId App Store Connect 中配置的推广优惠标识符
+// appAccountToken 客户账号的 UUID，与客户端购买时传入的一致，没有时传空字符串
+func (s *PromotionalOfferSigner) Sign(productId, offerId, appAccountToken string) (*PromotionalOfferSignature, error) {
+	nonce, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	timestamp := time.Now().UnixMilli()
+	signature, err := s.CreateSignature(productId, offerId, appAccountToken, nonce, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &PromotionalOfferSignature{
+		KeyIdentifier: s.keyIdentifier,
+		Nonce:         nonce,
+		Timestamp:     timestamp,
+		Signature:     signature,
+	}, nil
+}
+
+// CreateSignature 使用指定的 nonce 和 timestamp（UNIX 毫秒）签名，返回 Base64 编码的 ECDSA（SHA-256）签名。
+// 签名内容为 appBundleId、keyIdentifier、productIdentifier、offerIdentifier、小写的 appAccountToken、
+// 小写的 nonce 和 timestamp，以 U+2063 分隔
+func (s *PromotionalOfferSigner) CreateSignature(productId, offerId, appAccountToken, nonce string, timestamp int64) (string, error) {
+	if productId == "" || offerId == "" {
+		return "", errors.New("productId and offerId are required")
+	}
+	if appAccountToken != "" && !isUUID(appAccountToken) {
+		return "", fmt.Errorf("appAccountToken %q is not a UUID", appAccountToken)
+	}
+	if !isUUID(nonce) {
+		return "", fmt.Errorf("nonce %q is not a UUID", nonce)
+	}
+
+	payload := strings.Join([]string{
+		s.bundleId,
+		s.keyIdentifier,
+		productId,
+		offerId,
+		strings.ToLower(appAccountToken),
+		strings.ToLower(nonce),
+		strconv.FormatInt(timestamp, 10),
+	}, "\u2063")
+
+	digest := sha256.Sum256([]byte(payload))
+	signature, err := ecdsa.SignASN1(rand.Reader, s.privateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign promotional offer: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,94 @@
+package apple
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+// newTestP8 返回 App Store Connect 下载的 .p8 格式（PKCS#8 PEM）私钥
+func newTestP8(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestCreateSignature(t *testing.T) {
+	key := newTestKey(t)
+	signer, err := NewPromotionalOfferSigner(&Config{Kid: "KEY123", Bid: "com.example", PrivateKey: newTestP8(t, key)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		appAccountToken string
+		nonce           string
+		wantPayload     string
+		wantErr         bool
+	}{
+		{
+			name:            "uuids are lowercased",
+			appAccountToken: "7389A31A-FB6D-4569-A2A6-DB7D85D84813",
+			nonce:           "D4A9B7A2-2E3F-4F0F-9A3F-6C4D2D5B8E01",
+			wantPayload: strings.Join([]string{"com.example", "KEY123", "com.example.monthly", "WINBACK",
+				"7389a31a-fb6d-4569-a2a6-db7d85d84813", "d4a9b7a2-2e3f-4f0f-9a3f-6c4d2d5b8e01", "1698148900000"}, "\u2063"),
+		},
+		{
+			name:  "without appAccountToken",
+			nonce: "d4a9b7a2-2e3f-4f0f-9a3f-6c4d2d5b8e01",
+			wantPayload: strings.Join([]string{"com.example", "KEY123", "com.example.monthly", "WINBACK",
+				"", "d4a9b7a2-2e3f-4f0f-9a3f-6c4d2d5b8e01", "1698148900000"}, "\u2063"),
+		},
+		{name: "nonce is not a uuid", nonce: "nonce", wantErr: true},
+		{name: "appAccountToken is not a uuid", appAccountToken: "user-1", nonce: "d4a9b7a2-2e3f-4f0f-9a3f-6c4d2d5b8e01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signature, err := signer.CreateSignature("com.example.monthly", "WINBACK", tt.appAccountToken, tt.nonce, 1698148900000)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("CreateSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			raw, err := base64.StdEncoding.DecodeString(signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+			digest := sha256.Sum256([]byte(tt.wantPayload))
+			if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], raw) {
+				t.Fatalf("signature does not verify over %q", strings.ReplaceAll(tt.wantPayload, "\u2063", "|"))
+			}
+		})
+	}
+}
+
+func TestPromotionalOfferSignerSign(t *testing.T) {
+	signer, err := NewPromotionalOfferSigner(&Config{Kid: "KEY123", Bid: "com.example", PrivateKey: newTestP8(t, newTestKey(t))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := signer.Sign("com.example.monthly", "WINBACK", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := signer.Sign("com.example.monthly", "WINBACK", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.KeyIdentifier != "KEY123" || !isUUID(first.Nonce) || first.Nonce == second.Nonce || first.Timestamp == 0 {
+		t.Fatalf("signatures = %+v, %+v", first, second)
+	}
+
+	if _, err = NewPromotionalOfferSigner(&Config{Bid: "com.example", PrivateKey: newTestP8(t, newTestKey(t))}); err == nil {
+		t.Fatal("NewPromotionalOfferSigner() accepted a config without key id")
+	}
+}
@@ -0,0 +1,55 @@
+package apple
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrTransactionIdNotFound 收据中没有交易ID，例如应用收据中没有任何应用内购买
+var ErrTransactionIdNotFound = errors.New("receipt contains no transaction id")
+
+var (
+	purchaseInfoPattern  = regexp.MustCompile(`"purchase-info"\s+=\s+"([a-zA-Z0-9+/=]+)";`)
+	transactionIdPattern = regexp.MustCompile(`"transaction-id"\s+=\s+"([a-zA-Z0-9+/=]+)";`)
+)
+
+// ExtractTransactionIdFromAppReceipt 从 Base64 编码的应用收据中取出一笔应用内购买的交易ID，
+// 用于从 StoreKit 1 迁移：拿到交易ID后即可调用 Subscriptions、TransactionHistory 等接口。
+// 收据的签名不会被校验，没有应用内购买时返回 ErrTransactionIdNotFound
+func ExtractTransactionIdFromAppReceipt(appReceipt string) (string, error) {
+	receipt, err := DecodeAppReceipt(appReceipt)
+	if err != nil {
+		return "", err
+	}
+	for _, inApp := range receipt.InApp {
+		if inApp.TransactionId != "" {
+			return inApp.TransactionId, nil
+		}
+	}
+	return "", ErrTransactionIdNotFound
+}
+
+// ExtractTransactionIdFromTransactionReceipt 从 iOS 7 之前的 Base64 编码的交易收据（transactionReceipt）中取出交易ID，
+// 交易收据中的 purchase-info 同样是 Base64 编码的，其中包含 transaction-id
+func ExtractTransactionIdFromTransactionReceipt(transactionReceipt string) (string, error) {
+	receipt, err := base64.StdEncoding.DecodeString(transactionReceipt)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode transaction receipt: %v", err)
+	}
+	match := purchaseInfoPattern.FindSubmatch(receipt)
+	if match == nil {
+		return "", ErrTransactionIdNotFound
+	}
+
+	purchaseInfo, err := base64.StdEncoding.DecodeString(string(match[1]))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode purchase info: %v", err)
+	}
+	match = transactionIdPattern.FindSubmatch(purchaseInfo)
+	if match == nil {
+		return "", ErrTransactionIdNotFound
+	}
+	return string(match[1]), nil
+}
@@ -0,0 +1,43 @@
+package apple
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// RefundHistoryResponse 退款历史的一页数据
+type RefundHistoryResponse struct {
+	SignedTransactions []string `json:"signedTransactions"` // 已退款交易的签名交易信息，JWS 格式。
+	Revision           string   `json:"revision"`           // 下一页请求需要带上的 revision。
+	HasMore            bool     `json:"hasMore"`            // 是否还有更多已退款交易。
+}
+
+// GetRefundHistory 查询客户退款历史的一页（Get Refund History V2）：
+// transactionId 客户任意一笔交易的交易ID
+// revision 为空时查询第一页，之后传入上一页返回的 Revision
+func (c *Client) GetRefundHistory(ctx context.Context, transactionId string, revision string) (*RefundHistoryResponse, error) {
+	endpoint := c.endpoint("/inApps/v2/refund/lookup/%s", url.PathEscape(transactionId))
+	if revision != "" {
+		endpoint += "?" + url.Values{"revision": {revision}}.Encode()
+	}
+
+	response := &RefundHistoryResponse{}
+	if err := c.send(ctx, http.MethodGet, endpoint, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RefundHistory 遍历客户全部已退款的交易，自动请求后续分页，并通过 Client.Verifier 校验解码每一笔交易，
+// 退款时间和原因见 RevocationDate、RevocationReason；出错时产出 (nil, err) 后结束遍历
+func (c *Client) RefundHistory(ctx context.Context, transactionId string) iter.Seq2[*JWSTransactionDecodedPayload, error] {
+	return c.signedTransactionPages(func(revision string) ([]string, string, bool, error) {
+		page, err := c.GetRefundHistory(ctx, transactionId, revision)
+		if err != nil {
+			return nil, "", false, err
+		}
+		return page.SignedTransactions, page.Revision, page.HasMore, nil
+	})
+}
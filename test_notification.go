@@ -0,0 +1,202 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// SendTestNotificationResponse 请求发送测试通知的结果
+type SendTestNotificationResponse struct {
+	TestNotificationToken string `json:"testNotificationToken"` // 测试通知的令牌，用于查询发送结果。
+}
+
+// CheckTestNotificationResponse 测试通知的发送结果
+type CheckTestNotificationResponse struct {
+	SignedPayload      string             `json:"signedPayload"`      // App Store 签名的测试通知，JWS 格式。
+	SendAttemptResults []*SendAttemptItem `json:"sendAttemptResults"` // 每一次发送的结果。
+}
+
+// RequestTestNotification 请求 App Store 向配置的通知地址发送一条 TEST 通知（Request a Test Notification）
+func (c *Client) RequestTestNotification(ctx context.Context) (*SendTestNotificationResponse, error) {
+	response := &SendTestNotificationResponse{}
+	if err := c.send(ctx, http.MethodPost, c.endpoint("/inApps/v1/notifications/test"), nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetTestNotificationStatus 查询测试通知的发送结果（Get Test Notification Status）：
+// testNotificationToken RequestTestNotification 返回的令牌
+func (c *Client) GetTestNotificationStatus(ctx context.Context, testNotificationToken string) (*CheckTestNotificationResponse, error) {
+	endpoint := c.endpoint("/inApps/v1/notifications/test/%s", url.PathEscape(testNotificationToken))
+	response := &CheckTestNotificationResponse{}
+	if err := c.send(ctx, http.MethodGet, endpoint, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// testNotificationRetention 没有人等待时收到的 TEST 通知保留的时间，覆盖通知先于发送结果查询到达的情况
+const testNotificationRetention = 10 * time.Minute
+
+// TestNotificationWaiter 记录本服务收到的 TEST 通知，供 SendTestNotificationAndWait 等待。
+// 设置为 NotificationHandler.TestNotifications，或在自己的通知处理程序中校验通知后调用 Notify
+type TestNotificationWaiter struct {
+	mu      sync.Mutex
+	waiting map[string]*testNotificationWait // 正在等待的 notificationUUID
+	arrived map[string]time.Time             // 没有人等待时收到的 notificationUUID -> 过期时间
+}
+
+type testNotificationWait struct {
+	ch      chan struct{} // 收到通知时关闭
+	waiters int           // 正在等待的 Wait 调用数
+}
+
+// NewTestNotificationWaiter 创建 TestNotificationWaiter
+func NewTestNotificationWaiter() *TestNotificationWaiter {
+	return &TestNotificationWaiter{
+		waiting: map[string]*testNotificationWait{},
+		arrived: map[string]time.Time{},
+	}
+}
+
+// Notify 报告收到一条已校验的通知，非 TEST 通知会被忽略；没有人等待的通知保留 10 分钟后丢弃
+func (w *TestNotificationWaiter) Notify(notification *ResponseBodyV2DecodedPayload) {
+	if notification == nil || notification.NotificationType != NotificationTypeTest {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for uuid, expiresAt := range w.arrived {
+		if !now.Before(expiresAt) {
+			delete(w.arrived, uuid)
+		}
+	}
+	if wait, ok := w.waiting[notification.NotificationUUID]; ok {
+		select {
+		case <-wait.ch:
+		default:
+			close(wait.ch)
+		}
+		return
+	}
+	w.arrived[notification.NotificationUUID] = now.Add(testNotificationRetention)
+}
+
+// Wait 等待指定 notificationUUID 的 TEST 通知，通知在调用 Wait 之前 10 分钟内到达时立即返回
+func (w *TestNotificationWaiter) Wait(ctx context.Context, notificationUUID string) error {
+	w.mu.Lock()
+	if expiresAt, ok := w.arrived[notificationUUID]; ok {
+		delete(w.arrived, notificationUUID)
+		if time.Now().Before(expiresAt) {
+			w.mu.Unlock()
+			return nil
+		}
+	}
+	wait, ok := w.waiting[notificationUUID]
+	if !ok {
+		wait = &testNotificationWait{ch: make(chan struct{})}
+		w.waiting[notificationUUID] = wait
+	}
+	wait.waiters++
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if wait.waiters--; wait.waiters == 0 {
+			delete(w.waiting, notificationUUID)
+		}
+	}()
+
+	select {
+	case <-wait.ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TestNotificationError 在 ctx 结束前没有收到测试通知
+type TestNotificationError struct {
+	TestNotificationToken string             // 测试通知的令牌
+	NotificationUUID      string             // 测试通知的 notificationUUID，没能查询到时为空
+	SendAttemptResults    []*SendAttemptItem // 最后一次查询到的发送结果
+	Err                   error              // ctx 结束的原因或查询失败的原因
+}
+
+func (e *TestNotificationError) Error() string {
+	msg := fmt.Sprintf("test notification %s was not received", e.TestNotificationToken)
+	if n := len(e.SendAttemptResults); n > 0 {
+		msg += fmt.Sprintf(" (last send attempt: %s)", e.SendAttemptResults[n-1].SendAttemptResult)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *TestNotificationError) Unwrap() error {
+	return e.Err
+}
+
+// SendTestNotificationAndWait 请求一条测试通知，并等待 waiter 报告本服务收到了这条通知，可作为部署后的冒烟测试。
+// 需要设置 Client.Verifier 用于解码测试通知；等待期间每 5 秒查询一次发送结果，
+// ctx 结束时返回 *TestNotificationError，其中包含 App Store 最后一次的发送结果，例如 TLS_ISSUE
+func (c *Client) SendTestNotificationAndWait(ctx context.Context, waiter *TestNotificationWaiter) (*ResponseBodyV2DecodedPayload, error) {
+	// 没有 waiter 时无法确认收到通知，不应请求 App Store 发送
+	if waiter == nil {
+		return nil, errors.New("test notification waiter is required")
+	}
+	verifier, err := c.verifier()
+	if err != nil {
+		return nil, err
+	}
+	sent, err := c.RequestTestNotification(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := &TestNotificationError{TestNotificationToken: sent.TestNotificationToken}
+	var notification *ResponseBodyV2DecodedPayload
+	var received chan error
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		status, err := c.GetTestNotificationStatus(ctx, sent.TestNotificationToken)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			failed.SendAttemptResults = status.SendAttemptResults
+			if notification == nil && status.SignedPayload != "" {
+				if notification, err = verifier.VerifyAndDecodeNotification(status.SignedPayload); err != nil {
+					return nil, err
+				}
+				failed.NotificationUUID = notification.NotificationUUID
+
+				received = make(chan error, 1)
+				go func() { received <- waiter.Wait(ctx, notification.NotificationUUID) }()
+			}
+		case !(errors.As(err, &apiErr) && apiErr.Retryable()) && ctx.Err() == nil:
+			return nil, err
+		}
+
+		select {
+		case err = <-received:
+			if err == nil {
+				return notification, nil
+			}
+			failed.Err = err
+			return nil, failed
+		case <-ctx.Done():
+			failed.Err = ctx.Err()
+			return nil, failed
+		case <-ticker.C:
+		}
+	}
+}
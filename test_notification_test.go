@@ -0,0 +1,86 @@
+package apple
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestSendTestNotificationAndWait(t *testing.T) {
+	p := newTestPKI(t, "")
+	signedPayload := p.sign(t, jwt.MapClaims{
+		"notificationType": string(NotificationTypeTest),
+		"notificationUUID": "uuid-test",
+		"signedDate":       time.Now().UnixMilli(),
+		"data":             map[string]any{"bundleId": "com.example", "environment": "Sandbox"},
+	})
+
+	tests := []struct {
+		name         string
+		waiter       *TestNotificationWaiter
+		received     bool // 本服务是否收到了测试通知
+		wantRequests int
+		wantErr      func(err error) bool
+	}{
+		{
+			name:     "received",
+			waiter:   NewTestNotificationWaiter(),
+			received: true, wantRequests: 2,
+			wantErr: func(err error) bool { return err == nil },
+		},
+		{
+			name:         "not received",
+			waiter:       NewTestNotificationWaiter(),
+			wantRequests: 2,
+			wantErr: func(err error) bool {
+				var failed *TestNotificationError
+				return errors.As(err, &failed) && failed.NotificationUUID == "uuid-test" &&
+					len(failed.SendAttemptResults) == 1 && failed.SendAttemptResults[0].SendAttemptResult == SendAttemptResultTimedOut
+			},
+		},
+		{
+			name:    "nil waiter",
+			wantErr: func(err error) bool { return err != nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			client := newTestClient(t, p, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/inApps/v1/notifications/test":
+					w.Write([]byte(`{"testNotificationToken":"token"}`))
+				case r.Method == http.MethodGet && r.URL.Path == "/inApps/v1/notifications/test/token":
+					json.NewEncoder(w).Encode(CheckTestNotificationResponse{
+						SignedPayload:      signedPayload,
+						SendAttemptResults: []*SendAttemptItem{{SendAttemptResult: SendAttemptResultTimedOut}},
+					})
+				default:
+					t.Errorf("request = %s %s", r.Method, r.URL.Path)
+				}
+			})
+			if tt.received {
+				tt.waiter.Notify(&ResponseBodyV2DecodedPayload{NotificationType: NotificationTypeTest, NotificationUUID: "uuid-test"})
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			notification, err := client.SendTestNotificationAndWait(ctx, tt.waiter)
+			if !tt.wantErr(err) {
+				t.Fatalf("SendTestNotificationAndWait() error = %v", err)
+			}
+			if err == nil && notification.NotificationUUID != "uuid-test" {
+				t.Fatalf("notificationUUID = %q", notification.NotificationUUID)
+			}
+			if requests != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package apple
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// TransactionInfoResponse 单笔交易的信息
+type TransactionInfoResponse struct {
+	SignedTransactionInfo string                        `json:"signedTransactionInfo"` // App Store 签名的交易信息，JWS 格式。
+	TransactionInfo       *JWSTransactionDecodedPayload `json:"-"`                     // 校验并解码后的交易信息。
+}
+
+// GetTransactionInfo 查询单笔交易的信息（Get Transaction Info），适用于任意类型的应用内购买：
+// transactionId 交易ID
+func (c *Client) GetTransactionInfo(ctx context.Context, transactionId string) (*TransactionInfoResponse, error) {
+	verifier, err := c.verifier()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TransactionInfoResponse{}
+	err = c.send(ctx, http.MethodGet, c.endpoint("/inApps/v1/transactions/%s", url.PathEscape(transactionId)), nil, response)
+	if err != nil {
+		return nil, err
+	}
+
+	response.TransactionInfo, err = verifier.VerifyAndDecodeTransaction(response.SignedTransactionInfo)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,195 @@
+package apple
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxNotificationBodySize 通知请求体的最大长度
+const maxNotificationBodySize = 1 << 20
+
+var (
+	// ErrInvalidAppIdentifier 签名数据中的 bundleId 或 appAppleId 与校验器不一致
+	ErrInvalidAppIdentifier = errors.New("signed data does not belong to this app")
+	// ErrInvalidEnvironment 签名数据中的环境与校验器不一致
+	ErrInvalidEnvironment = errors.New("signed data is from another environment")
+)
+
+// SignedDataVerifier 校验 App Store 签名数据，并确认数据属于当前应用（Bundle ID、App Apple ID）和当前环境
+type SignedDataVerifier struct {
+	store       *TrustStore
+	bundleId    string
+	appAppleId  int64
+	environment Environment
+}
+
+// NewSignedDataVerifier 创建签名数据校验器：
+// config 提供 Bundle ID 和环境（Sandbox），appAppleId 为应用在 App Store 中的标识符，生产环境必须提供；
+// store 为 nil 时信任内置的 Apple 根证书
+func NewSignedDataVerifier(config *Config, appAppleId int64, store *TrustStore) (*SignedDataVerifier, error) {
+	if config.Bid == "" {
+		return nil, errors.New("bundle id is required")
+	}
+	environment := EnvironmentProduction
+	if config.Sandbox {
+		environment = EnvironmentSandbox
+	}
+	if environment == EnvironmentProduction && appAppleId == 0 {
+		return nil, errors.New("app apple id is required in production")
+	}
+	return &SignedDataVerifier{
+		store:       store,
+		bundleId:    config.Bid,
+		appAppleId:  appAppleId,
+		environment: environment,
+	}, nil
+}
+
+// VerifyAndDecodeTransaction 校验并解码 signedTransactionInfo
+func (v *SignedDataVerifier) VerifyAndDecodeTransaction(signedTransaction string) (*JWSTransactionDecodedPayload, error) {
+	transaction := &JWSTransactionDecodedPayload{}
+	if err := verifySignedPayload(v.store, signedTransaction, transaction); err != nil {
+		return nil, err
+	}
+	if err := v.checkBundleId(transaction.BundleId); err != nil {
+		return nil, err
+	}
+	if err := v.checkEnvironment(transaction.Environment); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// VerifyAndDecodeRenewalInfo 校验并解码 signedRenewalInfo
+func (v *SignedDataVerifier) VerifyAndDecodeRenewalInfo(signedRenewalInfo string) (*JWSRenewalInfoDecodedPayload, error) {
+	renewalInfo := &JWSRenewalInfoDecodedPayload{}
+	if err := verifySignedPayload(v.store, signedRenewalInfo, renewalInfo); err != nil {
+		return nil, err
+	}
+	if err := v.checkEnvironment(renewalInfo.Environment); err != nil {
+		return nil, err
+	}
+	return renewalInfo, nil
+}
+
+// VerifyAndDecodeNotification 校验并解码 App Store 服务器通知 V2 的 signedPayload，
+// 同时校验解码 data 中的 signedTransactionInfo 和 signedRenewalInfo，填充到 TransactionInfo 和 RenewalInfo
+func (v *SignedDataVerifier) VerifyAndDecodeNotification(signedPayload string) (*ResponseBodyV2DecodedPayload, error) {
+	notification := &ResponseBodyV2DecodedPayload{}
+	if err := verifySignedPayload(v.store, signedPayload, notification); err != nil {
+		return nil, err
+	}
+
+	var (
+		bundleId    string
+		appAppleId  int64
+		environment Environment
+	)
+	switch {
+	case notification.Data != nil:
+		bundleId, appAppleId, environment = notification.Data.BundleId, notification.Data.AppAppleId, notification.Data.Environment
+	case notification.Summary != nil:
+		bundleId, appAppleId, environment = notification.Summary.BundleId, notification.Summary.AppAppleId, notification.Summary.Environment
+	case notification.ExternalPurchaseToken != nil:
+		// 外部购买令牌没有 environment 字段，沙箱环境的 externalPurchaseId 以 SANDBOX 开头
+		token := notification.ExternalPurchaseToken
+		bundleId, appAppleId, environment = token.BundleId, token.AppAppleId, EnvironmentProduction
+		if strings.HasPrefix(token.ExternalPurchaseId, "SANDBOX") {
+			environment = EnvironmentSandbox
+		}
+	default:
+		return nil, errors.New("notification has no data, summary or externalPurchaseToken")
+	}
+
+	if err := v.checkBundleId(bundleId); err != nil {
+		return nil, err
+	}
+	if err := v.checkEnvironment(environment); err != nil {
+		return nil, err
+	}
+	if err := v.checkAppAppleId(appAppleId); err != nil {
+		return nil, err
+	}
+
+	if data := notification.Data; data != nil {
+		var err error
+		if data.SignedTransactionInfo != "" {
+			if data.TransactionInfo, err = v.VerifyAndDecodeTransaction(data.SignedTransactionInfo); err != nil {
+				return nil, fmt.Errorf("invalid signedTransactionInfo: %w", err)
+			}
+		}
+		if data.SignedRenewalInfo != "" {
+			if data.RenewalInfo, err = v.VerifyAndDecodeRenewalInfo(data.SignedRenewalInfo); err != nil {
+				return nil, fmt.Errorf("invalid signedRenewalInfo: %w", err)
+			}
+		}
+	}
+	return notification, nil
+}
+
+// VerifyAndDecodeResponseBodyV2 读取 App Store 服务器通知 V2 的请求体（ResponseBodyV2），校验并解码其中的 signedPayload
+func (v *SignedDataVerifier) VerifyAndDecodeResponseBodyV2(body io.Reader) (*ResponseBodyV2DecodedPayload, error) {
+	responseBody := &ResponseBodyV2{}
+	if err := json.NewDecoder(io.LimitReader(body, maxNotificationBodySize)).Decode(responseBody); err != nil {
+		return nil, fmt.Errorf("invalid notification body: %w", err)
+	}
+	if responseBody.SignedPayload == "" {
+		return nil, errors.New("invalid notification body: signedPayload is required")
+	}
+	return v.VerifyAndDecodeNotification(responseBody.SignedPayload)
+}
+
+// VerifyAndDecodeAppTransaction 校验并解码 StoreKit 提供的 AppTransaction JWS
+func (v *SignedDataVerifier) VerifyAndDecodeAppTransaction(signedAppTransaction string) (*AppTransaction, error) {
+	appTransaction := &AppTransaction{}
+	if err := verifySignedPayload(v.store, signedAppTransaction, appTransaction); err != nil {
+		return nil, err
+	}
+	if err := v.checkBundleId(appTransaction.BundleId); err != nil {
+		return nil, err
+	}
+	if err := v.checkEnvironment(appTransaction.ReceiptType); err != nil {
+		return nil, err
+	}
+	if err := v.checkAppAppleId(appTransaction.AppAppleId); err != nil {
+		return nil, err
+	}
+	return appTransaction, nil
+}
+
+// VerifyAndDecodeSummary 校验并解码批量延长续订日期完成后的 SUMMARY 通知，返回其中的汇总信息
+func (v *SignedDataVerifier) VerifyAndDecodeSummary(signedPayload string) (*NotificationSummary, error) {
+	notification, err := v.VerifyAndDecodeNotification(signedPayload)
+	if err != nil {
+		return nil, err
+	}
+	if notification.Summary == nil {
+		return nil, fmt.Errorf("notification %s has no summary", notification.NotificationUUID)
+	}
+	return notification.Summary, nil
+}
+
+func (v *SignedDataVerifier) checkBundleId(bundleId string) error {
+	if bundleId != v.bundleId {
+		return fmt.Errorf("%w: bundle id %q", ErrInvalidAppIdentifier, bundleId)
+	}
+	return nil
+}
+
+// checkAppAppleId 只在生产环境校验 appAppleId，沙箱环境中的应用还没有 App Apple ID
+func (v *SignedDataVerifier) checkAppAppleId(appAppleId int64) error {
+	if v.environment == EnvironmentProduction && appAppleId != v.appAppleId {
+		return fmt.Errorf("%w: app apple id %d", ErrInvalidAppIdentifier, appAppleId)
+	}
+	return nil
+}
+
+func (v *SignedDataVerifier) checkEnvironment(environment Environment) error {
+	if environment != v.environment {
+		return fmt.Errorf("%w: %q", ErrInvalidEnvironment, environment)
+	}
+	return nil
+}
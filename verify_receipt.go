@@ -0,0 +1,234 @@
+package apple
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/zeromicro/go-zero/core/logx"
+	"io"
+	"net/http"
+)
+
+// 旧版 verifyReceipt 接口的地址
+var VerifyReceiptURL = "https://buy.itunes.apple.com/verifyReceipt"
+var VerifyReceiptSandboxURL = "https://sandbox.itunes.apple.com/verifyReceipt"
+
+// ReceiptStatus verifyReceipt 返回的状态码
+type ReceiptStatus int32
+
+const (
+	ReceiptStatusOK                   ReceiptStatus = 0     // 收据有效
+	ReceiptStatusBadRequest           ReceiptStatus = 21000 // 请求不是 POST 或者格式错误
+	ReceiptStatusMalformedReceipt     ReceiptStatus = 21002 // receipt-data 格式错误或服务暂时不可用
+	ReceiptStatusUnauthenticated      ReceiptStatus = 21003 // 收据无法验证
+	ReceiptStatusSharedSecretMismatch ReceiptStatus = 21004 // 共享密钥与账号中的不一致
+	ReceiptStatusServerUnavailable    ReceiptStatus = 21005 // 收据服务器暂时不可用
+	ReceiptStatusSubscriptionExpired  ReceiptStatus = 21006 // 收据有效但订阅已过期，仅适用于 iOS 6 样式的交易收据
+	ReceiptStatusSandboxReceipt       ReceiptStatus = 21007 // 沙箱环境的收据被发送到了生产环境
+	ReceiptStatusProductionReceipt    ReceiptStatus = 21008 // 生产环境的收据被发送到了沙箱环境
+	ReceiptStatusInternalDataAccess   ReceiptStatus = 21009 // 内部数据访问错误，稍后重试
+	ReceiptStatusAccountNotFound      ReceiptStatus = 21010 // 用户账号不存在或已删除
+	ReceiptStatusInternalErrorMin     ReceiptStatus = 21100 // 内部数据访问错误的最小值，21100 到 21199 均为内部数据访问错误
+	ReceiptStatusInternalErrorMax     ReceiptStatus = 21199 // 内部数据访问错误的最大值
+)
+
+var receiptStatusNames = map[ReceiptStatus]string{
+	ReceiptStatusOK:                   "OK",
+	ReceiptStatusBadRequest:           "BAD_REQUEST",
+	ReceiptStatusMalformedReceipt:     "MALFORMED_RECEIPT",
+	ReceiptStatusUnauthenticated:      "UNAUTHENTICATED",
+	ReceiptStatusSharedSecretMismatch: "SHARED_SECRET_MISMATCH",
+	ReceiptStatusServerUnavailable:    "SERVER_UNAVAILABLE",
+	ReceiptStatusSubscriptionExpired:  "SUBSCRIPTION_EXPIRED",
+	ReceiptStatusSandboxReceipt:       "SANDBOX_RECEIPT",
+	ReceiptStatusProductionReceipt:    "PRODUCTION_RECEIPT",
+	ReceiptStatusInternalDataAccess:   "INTERNAL_DATA_ACCESS_ERROR",
+	ReceiptStatusAccountNotFound:      "ACCOUNT_NOT_FOUND",
+}
+
+func (s ReceiptStatus) String() string {
+	if s >= ReceiptStatusInternalErrorMin && s <= ReceiptStatusInternalErrorMax {
+		return fmt.Sprintf("INTERNAL_DATA_ACCESS_ERROR(%d)", int32(s))
+	}
+	return enumString(receiptStatusNames, s, "ReceiptStatus")
+}
+
+// ReceiptStatusError verifyReceipt 返回了非 0 的状态码
+type ReceiptStatusError struct {
+	Status      ReceiptStatus // 状态码
+	IsRetryable bool          // Apple 是否建议稍后重试
+}
+
+func (e *ReceiptStatusError) Error() string {
+	return fmt.Sprintf("verify receipt: status %d %s", e.Status, e.Status)
+}
+
+// Retryable 是否可以稍后重试
+func (e *ReceiptStatusError) Retryable() bool {
+	return e.IsRetryable || e.Status == ReceiptStatusServerUnavailable || e.Status == ReceiptStatusInternalDataAccess ||
+		e.Status >= ReceiptStatusInternalErrorMin && e.Status <= ReceiptStatusInternalErrorMax
+}
+
+// VerifyReceiptRequest verifyReceipt 的请求
+type VerifyReceiptRequest struct {
+	ReceiptData            string `json:"receipt-data"`                       // Base64 编码的收据。
+	Password               string `json:"password,omitempty"`                 // App 专用共享密钥，收据包含自动续订订阅时必填。
+	ExcludeOldTransactions bool   `json:"exclude-old-transactions,omitempty"` // 为 true 时 latest_receipt_info 只包含每个订阅的最新续订交易。
+}
+
+// VerifyReceiptResponse verifyReceipt 的响应，日期字段均为字符串形式的 UNIX 毫秒时间戳
+type VerifyReceiptResponse struct {
+	Status             ReceiptStatus         `json:"status"`               // 状态码，0 表示收据有效。
+	Environment        Environment           `json:"environment"`          // 生成收据的环境。
+	IsRetryable        bool                  `json:"is-retryable"`         // 请求遇到临时问题时为 true，可以稍后重试。
+	Receipt            *Receipt              `json:"receipt"`              // 解码后的收据。
+	LatestReceipt      string                `json:"latest_receipt"`       // 最新的 Base64 编码的收据，仅包含自动续订订阅时返回。
+	LatestReceiptInfo  []*ReceiptInApp       `json:"latest_receipt_info"`  // 全部应用内购买交易，包含续订交易。
+	PendingRenewalInfo []*PendingRenewalInfo `json:"pending_renewal_info"` // 每个自动续订订阅的续订信息。
+}
+
+// Receipt verifyReceipt 返回的解码后的收据
+type Receipt struct {
+	ReceiptType                string          `json:"receipt_type"`                 // 收据类型，例如 Production、ProductionSandbox。
+	AdamId                     int64           `json:"adam_id"`                      // 应用在 App Store 中的唯一标识符，沙箱环境为 0。
+	AppItemId                  int64           `json:"app_item_id"`                  // 与 AdamId 相同。
+	BundleId                   string          `json:"bundle_id"`                    // 应用的 Bundle ID。
+	ApplicationVersion         string          `json:"application_version"`          // 应用的版本号（CFBundleVersion）。
+	DownloadId                 int64           `json:"download_id"`                  // 应用下载交易的唯一标识符。
+	VersionExternalIdentifier  int64           `json:"version_external_identifier"`  // 应用版本的标识符。
+	ReceiptCreationDateMs      string          `json:"receipt_creation_date_ms"`     // 收据创建的时间。
+	RequestDateMs              string          `json:"request_date_ms"`              // 请求 verifyReceipt 的时间。
+	OriginalPurchaseDateMs     string          `json:"original_purchase_date_ms"`    // 客户首次下载应用的时间。
+	OriginalApplicationVersion string          `json:"original_application_version"` // 客户首次下载的应用版本号。
+	PreorderDateMs             string          `json:"preorder_date_ms"`             // 客户预订应用的时间，没有预订时为空。
+	ExpirationDateMs           string          `json:"expiration_date_ms"`           // 批量购买计划中收据的过期时间。
+	InApp                      []*ReceiptInApp `json:"in_app"`                       // 收据中的应用内购买交易。
+}
+
+// ReceiptInApp 收据中的一笔应用内购买交易
+type ReceiptInApp struct {
+	Quantity                    string `json:"quantity"`                      // 购买的数量。
+	ProductId                   string `json:"product_id"`                    // 产品标识符。
+	TransactionId               string `json:"transaction_id"`                // 交易标识符。
+	OriginalTransactionId       string `json:"original_transaction_id"`       // 原始购买的交易标识符。
+	WebOrderLineItemId          string `json:"web_order_line_item_id"`        // 跨设备的订阅购买事件标识符。
+	PurchaseDateMs              string `json:"purchase_date_ms"`              // 购买或续订的时间。
+	OriginalPurchaseDateMs      string `json:"original_purchase_date_ms"`     // 原始购买的时间。
+	ExpiresDateMs               string `json:"expires_date_ms"`               // 订阅到期或续订的时间。
+	CancellationDateMs          string `json:"cancellation_date_ms"`          // 退款或撤销的时间。
+	CancellationReason          string `json:"cancellation_reason"`           // 退款的原因，1：应用问题，0：其他原因。
+	IsTrialPeriod               string `json:"is_trial_period"`               // 是否处于免费试用期，"true" 或 "false"。
+	IsInIntroOfferPeriod        string `json:"is_in_intro_offer_period"`      // 是否处于推介促销期，"true" 或 "false"。
+	IsUpgraded                  string `json:"is_upgraded"`                   // 是否因升级而被取消，"true" 或不返回。
+	InAppOwnershipType          string `json:"in_app_ownership_type"`         // 购买或家庭共享获得。
+	SubscriptionGroupIdentifier string `json:"subscription_group_identifier"` // 订阅组的标识符。
+	PromotionalOfferId          string `json:"promotional_offer_id"`          // 兑换的推广优惠的标识符。
+	OfferCodeRefName            string `json:"offer_code_ref_name"`           // 兑换的优惠代码的参考名称。
+	AppAccountToken             string `json:"app_account_token"`             // 购买时传入的 appAccountToken。
+}
+
+// PendingRenewalInfo 自动续订订阅的续订信息
+type PendingRenewalInfo struct {
+	OriginalTransactionId    string `json:"original_transaction_id"`      // 订阅的原始交易标识符。
+	ProductId                string `json:"product_id"`                   // 当前订阅的产品标识符。
+	AutoRenewProductId       string `json:"auto_renew_product_id"`        // 下次续订的产品标识符。
+	AutoRenewStatus          string `json:"auto_renew_status"`            // 是否自动续订，"1" 开启，"0" 关闭。
+	ExpirationIntent         string `json:"expiration_intent"`            // 订阅过期的原因。
+	IsInBillingRetryPeriod   string `json:"is_in_billing_retry_period"`   // 是否处于计费重试期，"1" 或 "0"。
+	GracePeriodExpiresDateMs string `json:"grace_period_expires_date_ms"` // 计费宽限期结束的时间。
+	PriceConsentStatus       string `json:"price_consent_status"`         // 客户是否同意涨价，"1" 或 "0"。
+	PriceIncreaseStatus      string `json:"price_increase_status"`        // 涨价状态，"1" 或 "0"。
+	PromotionalOfferId       string `json:"promotional_offer_id"`         // 下次续订使用的推广优惠的标识符。
+	OfferCodeRefName         string `json:"offer_code_ref_name"`          // 下次续订使用的优惠代码的参考名称。
+}
+
+// ReceiptClient 旧版 verifyReceipt 接口的客户端，用于迁移期间校验 StoreKit 1 的 Base64 收据
+type ReceiptClient struct {
+	Config                 *Config      // 使用 Config.Sandbox 选择首先请求的环境
+	Password               string       // App 专用共享密钥
+	ExcludeOldTransactions bool         // 为 true 时只返回每个订阅的最新续订交易
+	HTTPClient             *http.Client // 为空时使用 http.DefaultClient
+}
+
+// NewReceiptClient 创建 verifyReceipt 客户端，password 为 App Store Connect 中的 App 专用共享密钥
+func NewReceiptClient(config *Config, password string) *ReceiptClient {
+	return &ReceiptClient{Config: config, Password: password}
+}
+
+// VerifyReceipt 校验 Base64 编码的收据：先请求 Config.Sandbox 对应的环境，
+// 生产环境返回 21007 时自动改为请求沙箱环境，沙箱环境返回 21008 时自动改为请求生产环境。
+// 状态码不为 0 时同时返回响应和 *ReceiptStatusError
+func (c *ReceiptClient) VerifyReceipt(ctx context.Context, receiptData string) (*VerifyReceiptResponse, error) {
+	if receiptData == "" {
+		return nil, errors.New("receipt data is required")
+	}
+	request := &VerifyReceiptRequest{
+		ReceiptData:            receiptData,
+		Password:               c.Password,
+		ExcludeOldTransactions: c.ExcludeOldTransactions,
+	}
+
+	endpoint := VerifyReceiptURL
+	if c.Config != nil && c.Config.Sandbox {
+		endpoint = VerifyReceiptSandboxURL
+	}
+	response, err := c.post(ctx, endpoint, request)
+	if err != nil {
+		return nil, err
+	}
+	switch response.Status {
+	case ReceiptStatusSandboxReceipt:
+		response, err = c.post(ctx, VerifyReceiptSandboxURL, request)
+	case ReceiptStatusProductionReceipt:
+		response, err = c.post(ctx, VerifyReceiptURL, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Status != ReceiptStatusOK {
+		return response, &ReceiptStatusError{Status: response.Status, IsRetryable: response.IsRetryable}
+	}
+	return response, nil
+}
+
+func (c *ReceiptClient) post(ctx context.Context, url string, request *VerifyReceiptRequest) (*VerifyReceiptResponse, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	logx.Debugf("method: %s, url: %s", http.MethodPost, url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("verify receipt: %d %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	response := &VerifyReceiptResponse{}
+	if err = json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("failed to parse verify receipt response: %v", err)
+	}
+	return response, nil
+}